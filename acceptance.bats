@@ -2,12 +2,24 @@
 
 @test "[CHECK] Using manifest returns newer image" {
   run ./sinker check --manifest example
-  [[ "$output" =~ "New versions for" ]]
+  [[ "$output" =~ "New versions found" ]]
 }
 
 @test "[CHECK] Using --images flag returns newer versions" {
   run ./sinker check --images plexsystems/sinker-test:0.0.1
-  [[ "$output" =~ "New versions for" ]]
+  [[ "$output" =~ "New versions found" ]]
+}
+
+@test "[CHECK] Using --log-format json logs the image as a field" {
+  run ./sinker check --images plexsystems/sinker-test:0.0.1 --log-format json
+  [[ "$output" =~ '"image":"plexsystems/sinker-test:0.0.1"' ]]
+  [[ "$output" =~ '"msg":"New versions found"' ]]
+}
+
+@test "[CHECK] Using --quiet flag only logs errors" {
+  run ./sinker check --images plexsystems/sinker-test:0.0.1 --quiet
+  [ "$status" -eq 0 ]
+  [ -z "$output" ]
 }
 
 @test "[CREATE] New manifest with autodetection creates example manifest" {
@@ -51,7 +63,7 @@
 
 @test "[PUSH] Using --dryrun flag lists missing images" {
   run ./sinker push --dryrun --manifest test/push
-  [[ "$output" =~ "Image busybox:latest would be pushed as plexsystems/busybox:latest" ]]
+  [[ "$output" =~ 'msg="Image would be pushed" image="busybox:latest" phase=push target="plexsystems/busybox:latest"' ]]
 }
 
 @test "[PUSH] Using manifest all latest images successfully pushed" {
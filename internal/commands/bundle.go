@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isArchivePath returns true when the path refers to a tar archive rather
+// than an OCI layout directory.
+func isArchivePath(path string) bool {
+	return strings.HasSuffix(path, ".tar")
+}
+
+// writeArchive writes the contents of the directory into a tar archive
+// located at the specified path.
+func writeArchive(directory string, path string) error {
+	archiveFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create archive: %w", err)
+	}
+	defer archiveFile.Close()
+
+	tarWriter := tar.NewWriter(archiveFile)
+	err = filepath.Walk(directory, func(currentFilePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk path: %w", err)
+		}
+
+		relativePath, err := filepath.Rel(directory, currentFilePath)
+		if err != nil {
+			return fmt.Errorf("relative path: %w", err)
+		}
+
+		if relativePath == "." {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(fileInfo, "")
+		if err != nil {
+			return fmt.Errorf("file header: %w", err)
+		}
+		header.Name = filepath.ToSlash(relativePath)
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return fmt.Errorf("write header: %w", err)
+		}
+
+		if fileInfo.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(currentFilePath)
+		if err != nil {
+			return fmt.Errorf("open file: %w", err)
+		}
+		defer file.Close()
+
+		if _, err := io.Copy(tarWriter, file); err != nil {
+			return fmt.Errorf("write file: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk directory: %w", err)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("close writer: %w", err)
+	}
+
+	if err := archiveFile.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	return nil
+}
+
+// extractArchive extracts the tar archive located at the specified path
+// into the directory.
+func extractArchive(path string, directory string) error {
+	archiveFile, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer archiveFile.Close()
+
+	tarReader := tar.NewReader(archiveFile)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read header: %w", err)
+		}
+
+		// Entries that would be written outside of the directory are not
+		// expected in a bundle and are rejected.
+		entryPath := filepath.Join(directory, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(entryPath, filepath.Clean(directory)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid entry %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(entryPath, os.ModePerm); err != nil {
+				return fmt.Errorf("create directory: %w", err)
+			}
+		case tar.TypeReg:
+			if err := extractFile(tarReader, entryPath); err != nil {
+				return fmt.Errorf("extract file: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func extractFile(reader io.Reader, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	defer file.Close()
+
+	// Bundles contain container image layers which can be quite large, so
+	// the size of the entries is intentionally not limited.
+	if _, err := io.Copy(file, reader); err != nil { //nolint:gosec
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	return nil
+}
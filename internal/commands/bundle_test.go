@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsArchivePath(t *testing.T) {
+	if !isArchivePath("bundle.tar") {
+		t.Errorf("expected bundle.tar to be an archive path")
+	}
+
+	if isArchivePath("bundle") {
+		t.Errorf("expected bundle to not be an archive path")
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	directory := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(directory, "blobs", "sha256"), os.ModePerm); err != nil {
+		t.Fatal("mkdir:", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(directory, "blobs", "sha256", "abc"), []byte("layer"), os.ModePerm); err != nil {
+		t.Fatal("write file:", err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "bundle.tar")
+	if err := writeArchive(directory, archivePath); err != nil {
+		t.Fatal("write archive:", err)
+	}
+
+	archiveFile, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatal("open archive:", err)
+	}
+	defer archiveFile.Close()
+
+	var entries []string
+	tarReader := tar.NewReader(archiveFile)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			break
+		}
+
+		entries = append(entries, header.Name)
+	}
+
+	expected := []string{"blobs", "blobs/sha256", "blobs/sha256/abc"}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected entries %v, actual %v", expected, entries)
+	}
+
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Errorf("expected entry %s, actual %s", expected[i], entries[i])
+		}
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, ".images.yaml"), []byte("target: {}"), os.ModePerm); err != nil {
+		t.Fatal("write file:", err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "bundle.tar")
+	if err := writeArchive(directory, archivePath); err != nil {
+		t.Fatal("write archive:", err)
+	}
+
+	extractedDirectory := t.TempDir()
+	if err := extractArchive(archivePath, extractedDirectory); err != nil {
+		t.Fatal("extract archive:", err)
+	}
+
+	actual, err := os.ReadFile(filepath.Join(extractedDirectory, ".images.yaml"))
+	if err != nil {
+		t.Fatal("read file:", err)
+	}
+
+	if string(actual) != "target: {}" {
+		t.Errorf("unexpected file contents after extraction: %s", actual)
+	}
+}
@@ -2,31 +2,82 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/plexsystems/sinker/internal/docker"
 	"github.com/plexsystems/sinker/internal/manifest"
 
 	"github.com/hashicorp/go-version"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
 )
 
+// checkResult is the result of checking an image for newer versions.
+type checkResult struct {
+	Image         string         `json:"image" yaml:"image"`
+	Tag           string         `json:"tag" yaml:"tag"`
+	NewerVersions []string       `json:"newerVersions" yaml:"newerVersions"`
+	Newest        newestVersions `json:"newest" yaml:"newest"`
+	Drifted       bool           `json:"drifted" yaml:"drifted"`
+	Skipped       string         `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	Error         string         `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// newestVersions are the newest versions of an image within each semver level.
+type newestVersions struct {
+	Patch string `json:"patch" yaml:"patch"`
+	Minor string `json:"minor" yaml:"minor"`
+	Major string `json:"major" yaml:"major"`
+}
+
 func newCheckCommand() *cobra.Command {
 	cmd := cobra.Command{
 		Use:   "check",
 		Short: "Check for newer images",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if err := viper.BindPFlag("images", cmd.Flags().Lookup("images")); err != nil {
-				return fmt.Errorf("bind images flag: %w", err)
+			flags := []string{"images", "update", "level", "output", "drift", "lockfile", "fail-on", "junit"}
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			if !contains([]string{"patch", "minor", "major"}, viper.GetString("level")) {
+				return fmt.Errorf("unknown level %s (must be patch, minor or major)", viper.GetString("level"))
+			}
+
+			if !contains([]string{"", "patch", "minor", "major", "any"}, viper.GetString("fail-on")) {
+				return fmt.Errorf("unknown fail on %s (must be patch, minor, major or any)", viper.GetString("fail-on"))
+			}
+
+			if !contains([]string{"", "json", "yaml", "markdown", "table"}, viper.GetString("output")) {
+				return fmt.Errorf("unknown output %s (must be json, yaml, markdown or table)", viper.GetString("output"))
+			}
+
+			if viper.GetBool("update") && (len(viper.GetStringSlice("images")) > 0 || (len(args) > 0 && args[0] == "-")) {
+				return errors.New("update can only be used with a manifest")
+			}
+
+			if viper.GetBool("drift") && viper.GetString("lockfile") == "" && (len(viper.GetStringSlice("images")) > 0 || (len(args) > 0 && args[0] == "-")) {
+				return errors.New("drift can only be used with a manifest or a lockfile")
 			}
 
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+
+			// The usage is not relevant when the check fails because
+			// images are outdated or could not be checked.
+			cmd.SilenceUsage = true
+
 			var input string
 			if len(args) > 0 {
 				input = args[0]
@@ -41,85 +92,293 @@ func newCheckCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringSliceP("images", "i", []string{}, "List of images to check (e.g. host.com/repo:v1.0.0)")
+	cmd.Flags().Bool("update", false, "Update the tags in the manifest to the newest version allowed by the level")
+	cmd.Flags().String("level", "major", "Highest level of version change allowed when updating (patch, minor or major)")
+	cmd.Flags().StringP("output", "o", "", "Output format of the results (json, yaml, markdown or table)")
+	cmd.Flags().Bool("drift", false, "Check if the digest of the current tag has changed since it was copied to the target")
+	cmd.Flags().String("lockfile", "", "Path to the lockfile written by copy to check for drift against, instead of the target")
+	cmd.Flags().String("fail-on", "", "Exit with a non-zero exit code when an image has a newer version at or above the level (patch, minor, major or any)")
+	cmd.Flags().String("junit", "", "Path to write a JUnit XML report with a test case for each image to")
 
 	return &cmd
 }
 
 func runCheckCommand(input string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	start := time.Now()
+
+	ctx, cancel := newTimeoutContext(context.Background())
 	defer cancel()
 
-	client, err := docker.New(log.Infof)
+	client, err := newDockerClient()
 	if err != nil {
 		return fmt.Errorf("new client: %w", err)
 	}
 
-	var imagesToCheck []string
+	var imageManifest manifest.Manifest
+	var sourcesToCheck []manifest.Source
 	if input == "-" {
+		var imagesToCheck []string
 		imagesToCheck, err = manifest.GetImagesFromStandardInput()
+		sourcesToCheck = manifest.GetSourcesFromImages(imagesToCheck, "")
 	} else if len(viper.GetStringSlice("images")) > 0 {
-		imagesToCheck = viper.GetStringSlice("images")
+		sourcesToCheck = manifest.GetSourcesFromImages(viper.GetStringSlice("images"), "")
 	} else {
-		imageManifest, err := manifest.Get(viper.GetString("manifest"))
+		imageManifest, err = manifest.Get(viper.GetString("manifest"))
 		if err != nil {
 			return fmt.Errorf("get manifest: %w", err)
 		}
 
-		for _, source := range imageManifest.Sources {
-			imagesToCheck = append(imagesToCheck, source.Image())
-		}
+		sourcesToCheck = imageManifest.Sources
 	}
 	if err != nil {
 		return fmt.Errorf("get images to check: %w", err)
 	}
 
-	var images []docker.RegistryPath
-	for _, image := range imagesToCheck {
-		images = append(images, docker.RegistryPath(image))
+	var lockfile *manifest.Lockfile
+	if viper.GetString("lockfile") != "" {
+		foundLockfile, err := manifest.GetLockfile(viper.GetString("lockfile"))
+		if err != nil {
+			return fmt.Errorf("get lockfile: %w", err)
+		}
+
+		lockfile = &foundLockfile
 	}
 
-	for _, image := range images {
-		if image.Tag() == "" {
+	var results []checkResult
+	var updated bool
+	for s, source := range sourcesToCheck {
+		image := source.Image()
+		if source.Tag == "" {
+			continue
+		}
+
+		kind := "Image"
+		if source.IsArtifact() {
+			kind = "Artifact"
+		}
+
+		logger := imageLogger(image, "check")
+
+		result := checkResult{
+			Image:         image,
+			Tag:           source.Tag,
+			NewerVersions: []string{},
+		}
+
+		imageCtx, cancel := newImageContext(ctx, getImageTimeout(source))
+
+		// Drift is checked for every tag, including the tags that are not a valid
+		// version such as latest, as these are the tags most likely to be re-pushed.
+		if viper.GetBool("drift") {
+			drifted, err := hasDrifted(imageCtx, client, source, lockfile)
+			if err != nil {
+				cancel()
+				logger.WithError(err).Error("Unable to check for drift")
+
+				result.Error = fmt.Sprintf("check drift: %s", err)
+				results = append(results, result)
+				continue
+			}
+
+			if drifted {
+				logger.Info("Digest has changed since it was copied to the target")
+			}
+
+			result.Drifted = drifted
+		}
+
+		scheme, err := newVersionScheme(source.Versioning)
+		if err != nil {
+			cancel()
+			logger.WithError(err).Error("Invalid versioning")
+
+			result.Error = fmt.Sprintf("versioning: %s", err)
+			results = append(results, result)
 			continue
 		}
 
-		imageVersion, err := version.NewVersion(image.Tag())
+		imageVersion, err := scheme.parse(source.Tag)
 		if err != nil {
-			log.Infof("Image %s has an invalid version. Skipping ...", image)
+			cancel()
+			logger.Infof("%s has an invalid version. Skipping ...", kind)
+
+			result.Skipped = "invalid version"
+			results = append(results, result)
 			continue
 		}
 
-		tags, err := client.GetTagsForRepository(ctx, image.Host(), image.Repository())
+		tags, err := client.GetTagsForRepository(imageCtx, source.Host, source.Repository)
+		cancel()
 		if err != nil {
-			return fmt.Errorf("get tags: %w", err)
+			logger.WithError(err).Error("Unable to get tags")
+
+			result.Error = fmt.Sprintf("get tags: %s", err)
+			results = append(results, result)
+			continue
 		}
-		tags = filterTags(tags)
+		tags = scheme.filter(tags)
 
-		newerVersions := getNewerVersions(imageVersion, tags)
+		result.NewerVersions = append(result.NewerVersions, getAllNewerVersions(imageVersion, tags, scheme)...)
+		result.Newest = newestVersions{
+			Patch: getNewestVersion(imageVersion, tags, "patch", scheme),
+			Minor: getNewestVersion(imageVersion, tags, "minor", scheme),
+			Major: getNewestVersion(imageVersion, tags, "major", scheme),
+		}
+		results = append(results, result)
+
+		newerVersions := getNewerVersions(imageVersion, tags, scheme)
 		if len(newerVersions) == 0 {
-			log.Infof("Image %s is up to date!", image)
+			logger.Infof("%s is up to date!", kind)
+			continue
+		}
+
+		logger.WithField("versions", newerVersions).Info("New versions found")
+
+		if !viper.GetBool("update") {
+			continue
+		}
+
+		// Sources that are pinned to a digest are not updated, as the digest
+		// would no longer match the tag.
+		if source.Digest != "" {
+			logger.Infof("%s is pinned to a digest. Skipping update ...", kind)
 			continue
 		}
 
-		log.Infof("New versions for %v found: %v", image, newerVersions)
+		newestVersion := getNewestVersion(imageVersion, tags, viper.GetString("level"), scheme)
+		if newestVersion == "" {
+			logger.WithField("level", viper.GetString("level")).Info("No version found within the level. Skipping update ...")
+			continue
+		}
+
+		logger.WithField("version", newestVersion).Info("Updating tag")
+		imageManifest.Sources[s].Tag = newestVersion
+		updated = true
+	}
+
+	if updated {
+		if err := imageManifest.Write(viper.GetString("manifest")); err != nil {
+			return fmt.Errorf("write manifest: %w", err)
+		}
+	}
+
+	if viper.GetString("output") != "" {
+		if err := writeCheckResults(os.Stdout, results, viper.GetString("output")); err != nil {
+			return fmt.Errorf("write results: %w", err)
+		}
+	}
+
+	suite := newJUnitTestSuite("check", time.Since(start), getCheckTestCases(results, viper.GetString("fail-on")))
+	if err := writeJUnit(suite, viper.GetString("junit")); err != nil {
+		return fmt.Errorf("write junit: %w", err)
+	}
+
+	var errored int
+	for _, result := range results {
+		if result.Error != "" {
+			errored++
+		}
+	}
+
+	if errored > 0 {
+		return &ExitError{
+			Code: ExitCodeErrored,
+			Err:  fmt.Errorf("%d of %d images could not be checked", errored, len(results)),
+		}
+	}
+
+	if viper.GetString("fail-on") == "" {
+		return nil
+	}
+
+	var outdated int
+	for _, result := range results {
+		if exceedsFailOn(result, viper.GetString("fail-on")) {
+			outdated++
+		}
+	}
+
+	if outdated > 0 {
+		return &ExitError{
+			Code: ExitCodeOutdated,
+			Err:  fmt.Errorf("%d of %d images are outdated (fail on %s)", outdated, len(results), viper.GetString("fail-on")),
+		}
 	}
 
 	return nil
 }
 
-func getNewerVersions(currentVersion *version.Version, foundTags []string) []string {
-	var newerVersions []string
-	for _, foundTag := range foundTags {
-		tag, err := version.NewVersion(foundTag)
-		if err != nil {
-			continue
+// exceedsFailOn returns true if the image has a newer version at or above the
+// fail on level. When the level is any, every newer version exceeds it.
+func exceedsFailOn(result checkResult, failOn string) bool {
+	if failOn == "any" {
+		return len(result.NewerVersions) > 0
+	}
+
+	levels := map[string]int{"patch": 1, "minor": 2, "major": 3}
+	updateLevel := getUpdateLevel(result)
+	if updateLevel == "" {
+		return false
+	}
+
+	return levels[updateLevel] >= levels[failOn]
+}
+
+// getUpdateLevel returns the highest level (patch, minor or major) of the newer versions
+// of the image. The newest version of a higher level always differs from the newest
+// version of a lower level when there is a newer version at that level.
+func getUpdateLevel(result checkResult) string {
+	switch {
+	case result.Newest.Major != "" && result.Newest.Major != result.Newest.Minor:
+		return "major"
+	case result.Newest.Minor != "" && result.Newest.Minor != result.Newest.Patch:
+		return "minor"
+	case result.Newest.Patch != "":
+		return "patch"
+	default:
+		return ""
+	}
+}
+
+// hasDrifted returns true if the digest of the source has changed since it was copied.
+// The source is compared to the digest recorded in the lockfile when one is given,
+// otherwise it is compared to the target image.
+func hasDrifted(ctx context.Context, client docker.Client, source manifest.Source, lockfile *manifest.Lockfile) (bool, error) {
+	if lockfile != nil {
+		lockedDigest, exists := lockfile.Digest(source.Image())
+		if !exists {
+			sourceLogger(source, "drift").Info("Image was not found in the lockfile. Skipping drift check ...")
+			return false, nil
 		}
 
-		if currentVersion.LessThan(tag) {
-			newerVersions = append(newerVersions, tag.Original())
+		digest, err := client.GetDigest(ctx, source.Image())
+		if err != nil {
+			return false, fmt.Errorf("get source digest: %w", err)
 		}
+
+		return digest != lockedDigest, nil
+	}
+
+	targetDigests, err := client.GetImageDigests(ctx, source.TargetImage())
+	if docker.IsNotFoundError(err) {
+		sourceLogger(source, "drift").Info("Image was not found at the target. Skipping drift check ...")
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get target digests: %w", err)
 	}
 
+	sourceDigests, err := client.GetImageDigests(ctx, source.Image())
+	if err != nil {
+		return false, fmt.Errorf("get source digests: %w", err)
+	}
+
+	return len(getDriftedPlatforms(sourceDigests, targetDigests, getCompressionFormat(source) != "")) > 0, nil
+}
+
+func getNewerVersions(currentVersion *version.Version, foundTags []string, scheme versionScheme) []string {
+	newerVersions := getAllNewerVersions(currentVersion, foundTags, scheme)
+
 	// For images that are very out of date, the number of newer versions can be quite long.
 	// Only return the latest 5 releases to keep the list manageable.
 	if len(newerVersions) > 5 {
@@ -130,6 +389,76 @@ func getNewerVersions(currentVersion *version.Version, foundTags []string) []str
 	return newerVersions
 }
 
+// getAllNewerVersions returns all of the found tags that are newer than the current version.
+func getAllNewerVersions(currentVersion *version.Version, foundTags []string, scheme versionScheme) []string {
+	var newerVersions []string
+	for _, foundTag := range foundTags {
+		tag, err := scheme.parse(foundTag)
+		if err != nil {
+			continue
+		}
+
+		if isNewerVersion(currentVersion, tag, scheme) {
+			newerVersions = append(newerVersions, foundTag)
+		}
+	}
+
+	return newerVersions
+}
+
+// isNewerVersion returns true if the version is newer than the current version. Pre-releases
+// are only newer when the current version is a pre-release, or when the scheme includes
+// pre-releases.
+func isNewerVersion(currentVersion *version.Version, newVersion *version.Version, scheme versionScheme) bool {
+	if !currentVersion.LessThan(newVersion) {
+		return false
+	}
+
+	return newVersion.Prerelease() == "" || currentVersion.Prerelease() != "" || scheme.includesPreReleases()
+}
+
+// getNewestVersion returns the newest of the found tags that is newer than the current
+// version and within the given level (patch, minor or major) of the current version.
+func getNewestVersion(currentVersion *version.Version, foundTags []string, level string, scheme versionScheme) string {
+	var newestTag string
+	var newestVersion *version.Version
+	for _, foundTag := range foundTags {
+		tag, err := scheme.parse(foundTag)
+		if err != nil {
+			continue
+		}
+
+		if !isNewerVersion(currentVersion, tag, scheme) {
+			continue
+		}
+
+		if !isWithinLevel(currentVersion, tag, level) {
+			continue
+		}
+
+		if newestVersion == nil || newestVersion.LessThan(tag) {
+			newestTag = foundTag
+			newestVersion = tag
+		}
+	}
+
+	return newestTag
+}
+
+func isWithinLevel(currentVersion *version.Version, newVersion *version.Version, level string) bool {
+	currentSegments := currentVersion.Segments()
+	newSegments := newVersion.Segments()
+
+	switch level {
+	case "patch":
+		return currentSegments[0] == newSegments[0] && currentSegments[1] == newSegments[1]
+	case "minor":
+		return currentSegments[0] == newSegments[0]
+	default:
+		return true
+	}
+}
+
 func filterTags(tags []string) []string {
 	var filteredTags []string
 	for _, tag := range tags {
@@ -144,7 +473,6 @@ func filterTags(tags []string) []string {
 
 		// Remove tags that include architectures and other strings
 		// not necessarily related to a release.
-		allowedPreReleases := []string{"alpha", "beta", "rc"}
 		if strings.Contains(tag, "-") && !containsSubstring(allowedPreReleases, tag) {
 			continue
 		}
@@ -164,3 +492,53 @@ func containsSubstring(items []string, item string) bool {
 
 	return false
 }
+
+func writeCheckResults(writer io.Writer, results []checkResult, format string) error {
+	switch format {
+	case "json":
+		contents, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(writer, string(contents)); err != nil {
+			return fmt.Errorf("write json: %w", err)
+		}
+	case "yaml":
+		contents, err := yaml.Marshal(results)
+		if err != nil {
+			return fmt.Errorf("marshal yaml: %w", err)
+		}
+
+		if _, err := writer.Write(contents); err != nil {
+			return fmt.Errorf("write yaml: %w", err)
+		}
+	case "markdown":
+		if _, err := fmt.Fprintln(writer, "| Image | Tag | Newer | Patch | Minor | Major | Drifted | Error |\n| --- | --- | --- | --- | --- | --- | --- | --- |"); err != nil {
+			return fmt.Errorf("write header: %w", err)
+		}
+
+		for _, result := range results {
+			if _, err := fmt.Fprintf(writer, "| %s | %s | %s | %s | %s | %s | %v | %s |\n", result.Image, result.Tag, strings.Join(result.NewerVersions, ", "), result.Newest.Patch, result.Newest.Minor, result.Newest.Major, result.Drifted, result.Error); err != nil {
+				return fmt.Errorf("write result: %w", err)
+			}
+		}
+	case "table":
+		tableWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		if _, err := fmt.Fprintln(tableWriter, "IMAGE\tTAG\tNEWER\tPATCH\tMINOR\tMAJOR\tDRIFTED\tERROR"); err != nil {
+			return fmt.Errorf("write header: %w", err)
+		}
+
+		for _, result := range results {
+			if _, err := fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%s\t%s\t%v\t%s\n", result.Image, result.Tag, strings.Join(result.NewerVersions, ", "), result.Newest.Patch, result.Newest.Minor, result.Newest.Major, result.Drifted, result.Error); err != nil {
+				return fmt.Errorf("write result: %w", err)
+			}
+		}
+
+		if err := tableWriter.Flush(); err != nil {
+			return fmt.Errorf("flush: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 
@@ -31,7 +32,7 @@ func TestNewerVersions(t *testing.T) {
 
 	foundTags := []string{"v1.0.0", "v2.0.0", "v3.0.0", "v4.0.0", "v5.0.0", "v6.0.0"}
 
-	actual := getNewerVersions(currentTag, foundTags)
+	actual := getNewerVersions(currentTag, foundTags, versionScheme{})
 
 	expected := []string{"...", "v2.0.0", "v3.0.0", "v4.0.0", "v5.0.0", "v6.0.0"}
 
@@ -39,3 +40,186 @@ func TestNewerVersions(t *testing.T) {
 		t.Errorf("unexpected filtering of tags. expected %v actual %v", expected, actual)
 	}
 }
+
+func TestGetAllNewerVersionsPreReleases(t *testing.T) {
+	foundTags := []string{"v1.2.3", "v1.3.0-rc.1", "v1.3.0"}
+
+	testCases := []struct {
+		current  string
+		expected []string
+	}{
+		{"v1.2.3", []string{"v1.3.0"}},
+		{"v1.3.0-alpha.1", []string{"v1.3.0-rc.1", "v1.3.0"}},
+	}
+
+	for _, testCase := range testCases {
+		currentTag, err := version.NewVersion(testCase.current)
+		if err != nil {
+			t.Fatal("new version:", err)
+		}
+
+		actual := getAllNewerVersions(currentTag, foundTags, versionScheme{})
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("expected newer versions of %s to be %v, actual %v", testCase.current, testCase.expected, actual)
+		}
+	}
+}
+
+func TestGetNewestVersion(t *testing.T) {
+	foundTags := []string{"v1.2.3", "v1.2.4", "v1.3.0", "v1.4.0-rc.1", "v2.0.0", "latest"}
+
+	testCases := []struct {
+		current  string
+		level    string
+		expected string
+	}{
+		{"v1.2.3", "patch", "v1.2.4"},
+		{"v1.2.3", "minor", "v1.3.0"},
+		{"v1.2.3", "major", "v2.0.0"},
+		{"v2.0.0", "major", ""},
+		{"v1.4.0-alpha.1", "patch", "v1.4.0-rc.1"},
+	}
+
+	for _, testCase := range testCases {
+		currentVersion, err := version.NewVersion(testCase.current)
+		if err != nil {
+			t.Fatal("new version:", err)
+		}
+
+		actual := getNewestVersion(currentVersion, foundTags, testCase.level, versionScheme{})
+		if actual != testCase.expected {
+			t.Errorf("expected newest %s version of %s to be %s, actual %s", testCase.level, testCase.current, testCase.expected, actual)
+		}
+	}
+}
+
+func TestWriteCheckResults(t *testing.T) {
+	results := []checkResult{
+		{
+			Image:         "busybox:1.0.0",
+			Tag:           "1.0.0",
+			NewerVersions: []string{"1.0.1", "2.0.0"},
+			Newest:        newestVersions{Patch: "1.0.1", Major: "2.0.0"},
+		},
+		{
+			Image:         "private/app:1.0.0",
+			Tag:           "1.0.0",
+			NewerVersions: []string{},
+			Error:         "get tags: unauthorized",
+		},
+		{
+			Image:         "nginx:latest",
+			Tag:           "latest",
+			NewerVersions: []string{},
+			Skipped:       "invalid version",
+		},
+	}
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "json",
+			expected: `[
+  {
+    "image": "busybox:1.0.0",
+    "tag": "1.0.0",
+    "newerVersions": [
+      "1.0.1",
+      "2.0.0"
+    ],
+    "newest": {
+      "patch": "1.0.1",
+      "minor": "",
+      "major": "2.0.0"
+    },
+    "drifted": false
+  },
+  {
+    "image": "private/app:1.0.0",
+    "tag": "1.0.0",
+    "newerVersions": [],
+    "newest": {
+      "patch": "",
+      "minor": "",
+      "major": ""
+    },
+    "drifted": false,
+    "error": "get tags: unauthorized"
+  },
+  {
+    "image": "nginx:latest",
+    "tag": "latest",
+    "newerVersions": [],
+    "newest": {
+      "patch": "",
+      "minor": "",
+      "major": ""
+    },
+    "drifted": false,
+    "skipped": "invalid version"
+  }
+]
+`,
+		},
+		{
+			format: "markdown",
+			expected: `| Image | Tag | Newer | Patch | Minor | Major | Drifted | Error |
+| --- | --- | --- | --- | --- | --- | --- | --- |
+| busybox:1.0.0 | 1.0.0 | 1.0.1, 2.0.0 | 1.0.1 |  | 2.0.0 | false |  |
+| private/app:1.0.0 | 1.0.0 |  |  |  |  | false | get tags: unauthorized |
+| nginx:latest | latest |  |  |  |  | false |  |
+`,
+		},
+		{
+			format: "table",
+			expected: `IMAGE              TAG     NEWER         PATCH  MINOR  MAJOR  DRIFTED  ERROR
+busybox:1.0.0      1.0.0   1.0.1, 2.0.0  1.0.1         2.0.0  false    
+private/app:1.0.0  1.0.0                                      false    get tags: unauthorized
+nginx:latest       latest                                     false    
+`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		var actual bytes.Buffer
+		if err := writeCheckResults(&actual, results, testCase.format); err != nil {
+			t.Fatal("write check results:", err)
+		}
+
+		if actual.String() != testCase.expected {
+			t.Errorf("expected %s output %s, actual %s", testCase.format, testCase.expected, actual.String())
+		}
+	}
+}
+
+func TestExceedsFailOn(t *testing.T) {
+	minorUpdate := checkResult{
+		NewerVersions: []string{"1.0.1", "1.1.0"},
+		Newest:        newestVersions{Patch: "1.0.1", Minor: "1.1.0", Major: "1.1.0"},
+	}
+	preReleaseUpdate := checkResult{
+		NewerVersions: []string{"1.1.0-rc.1"},
+	}
+
+	testCases := []struct {
+		result   checkResult
+		failOn   string
+		expected bool
+	}{
+		{minorUpdate, "patch", true},
+		{minorUpdate, "minor", true},
+		{minorUpdate, "major", false},
+		{minorUpdate, "any", true},
+		{preReleaseUpdate, "patch", false},
+		{preReleaseUpdate, "any", true},
+	}
+
+	for _, testCase := range testCases {
+		actual := exceedsFailOn(testCase.result, testCase.failOn)
+		if actual != testCase.expected {
+			t.Errorf("expected %v to exceed fail on %s to be %v, actual %v", testCase.result.NewerVersions, testCase.failOn, testCase.expected, actual)
+		}
+	}
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/containers/image/v5/copy"
 	dockerv5 "github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/pkg/compression"
 	"github.com/containers/image/v5/signature"
 	"github.com/containers/image/v5/types"
 	"github.com/plexsystems/sinker/internal/docker"
 	"github.com/plexsystems/sinker/internal/manifest"
+	"github.com/plexsystems/sinker/internal/metrics"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -22,7 +25,10 @@ func newCopyCommand() *cobra.Command {
 		Use:   "copy",
 		Short: "Copy the images in the manifest directly from source to target repository",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			flags := []string{"dryrun", "images", "target", "force", "override-arch", "override-os", "all-variants"}
+			flags := []string{"dryrun", "images", "target", "force", "metrics-textfile", "report", "junit"}
+			flags = append(flags, copyFlags...)
+			flags = append(flags, signingFlags...)
+			flags = append(flags, failureFlags...)
 			for _, flag := range flags {
 				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
 					return fmt.Errorf("bind flag: %w", err)
@@ -33,11 +39,41 @@ func newCopyCommand() *cobra.Command {
 				return errors.New("target must be specified when using the images flag")
 			}
 
+			if err := validateCopyFlags(); err != nil {
+				return err
+			}
+
+			if err := validateFailureFlags(); err != nil {
+				return err
+			}
+
 			return nil
 		},
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := runCopyCommand(); err != nil {
+			ctx, cancel := newTimeoutContext(context.Background())
+			defer cancel()
+
+			// The metrics are also written when the copy fails, so that failures can be alerted on.
+			defer func() {
+				if err := writeMetricsTextfile(); err != nil {
+					log.WithError(err).Error("Unable to write metrics")
+				}
+			}()
+
+			report := newSyncReport("copy")
+			defer func() {
+				if err := writeReport(report, viper.GetString("report")); err != nil {
+					log.WithError(err).Error("Unable to write report")
+				}
+
+				suite := newJUnitTestSuite("copy", time.Since(report.Started), getSyncTestCases(report))
+				if err := writeJUnit(suite, viper.GetString("junit")); err != nil {
+					log.WithError(err).Error("Unable to write JUnit report")
+				}
+			}()
+
+			if err := runCopyCommand(ctx, report); err != nil {
 				return fmt.Errorf("copy: %w", err)
 			}
 
@@ -49,22 +85,84 @@ func newCopyCommand() *cobra.Command {
 	cmd.Flags().StringSliceP("images", "i", []string{}, "List of images to copy to target")
 	cmd.Flags().StringP("target", "t", "", "Registry the images will be copied to")
 	cmd.Flags().Bool("force", false, "Force the copy of the image even if already exists at the target")
-	cmd.Flags().StringP("override-arch", "a", "", "Architecture variant of the image if it is a multi-arch image")
-	cmd.Flags().StringP("override-os", "o", "", "Operating system variant of the image if it is a multi-os image")
-	cmd.Flags().Bool("all-variants", false, "Copy all variants of the image")
+	cmd.Flags().String("metrics-textfile", "", "Path to write the Prometheus metrics of the copy to for the node exporter textfile collector")
+	cmd.Flags().String("report", "", "Path to write a JSON report of the action taken for each image to")
+	cmd.Flags().String("junit", "", "Path to write a JUnit XML report with a test case for each image to")
+	addCopyFlags(&cmd)
+	addSigningFlags(&cmd)
+	addFailureFlags(&cmd)
 
 	return &cmd
 }
 
-func runCopyCommand() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
-	defer cancel()
+// compressionFormats are the compression formats of the layers that registries can store.
+var compressionFormats = []string{"gzip", "zstd", "zstd:chunked"}
+
+// validateCopyFlags returns an error when the copy flags are invalid or conflict with each other.
+func validateCopyFlags() error {
+	if err := validateCompressionFormat(viper.GetString("compression-format")); err != nil {
+		return err
+	}
+
+	if viper.GetBool("include-artifacts") && (viper.GetString("override-arch") != "" || viper.GetString("override-os") != "") {
+		return errors.New("include-artifacts copies every variant of the image and cannot be used with override-arch or override-os")
+	}
+
+	if viper.GetBool("include-artifacts") && viper.GetString("compression-format") != "" {
+		return errors.New("include-artifacts preserves the digest of the image and cannot be used with compression-format")
+	}
+
+	return nil
+}
+
+// validateCompressionFormat returns an error when the format is not a compression format
+// that registries can store layers in. An empty format keeps the compression of the source.
+func validateCompressionFormat(format string) error {
+	if format != "" && !contains(compressionFormats, format) {
+		return fmt.Errorf("unknown compression format %s (must be gzip, zstd or zstd:chunked)", format)
+	}
+
+	return nil
+}
+
+// validateSourceCompression returns an error when the compression of a source or its target
+// is invalid, so that the copy fails before any image is copied rather than part way through.
+func validateSourceCompression(sources []manifest.Source) error {
+	for _, source := range sources {
+		if err := validateCompressionFormat(source.Compression); err != nil {
+			return fmt.Errorf("source %s: %w", source.Image(), err)
+		}
+
+		if err := validateCompressionFormat(source.Target.Compression); err != nil {
+			return fmt.Errorf("target of %s: %w", source.Image(), err)
+		}
+	}
 
+	return nil
+}
+
+// copyFlags are the flags that configure how the images are copied to the target.
+var copyFlags = []string{"override-arch", "override-os", "all-variants", "include-artifacts", "policy", "registries-dir", "compression-format", "compression-level", "lockfile"}
+
+func addCopyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("override-arch", "a", "", "Architecture variant of the image if it is a multi-arch image")
+	cmd.Flags().StringP("override-os", "o", "", "Operating system variant of the image if it is a multi-os image")
+	cmd.Flags().Bool("all-variants", false, "Copy all variants of the image")
+	cmd.Flags().Bool("include-artifacts", false, "Copy the signatures, attestations, SBOMs and referrers attached to the image")
+	cmd.Flags().String("policy", "", "Path to a containers-policy.json file the source images must meet (defaults to accepting unsigned images)")
+	cmd.Flags().String("registries-dir", "", "Path to a registries.d directory configuring where signatures are stored")
+	cmd.Flags().String("compression-format", "", "Compression format of the layers written to the target (gzip, zstd or zstd:chunked)")
+	cmd.Flags().Int("compression-level", 0, "Compression level of the layers written to the target")
+	cmd.Flags().String("lockfile", "", "Path to a lockfile to record the digests of the source images in")
+}
+
+func runCopyCommand(ctx context.Context, report *syncReport) error {
 	// Use Docker client for queries that do not require access to docker socket.
-	client, err := docker.New(log.Infof)
+	client, err := newDockerClient()
 	if err != nil {
 		return fmt.Errorf("new client: %w", err)
 	}
+	defer report.resolveDigests(client)
 
 	var sources []manifest.Source
 	if len(viper.GetStringSlice("images")) > 0 {
@@ -78,76 +176,371 @@ func runCopyCommand() error {
 		sources = imageManifest.Sources
 	}
 
+	if err := validateSourceCompression(sources); err != nil {
+		return fmt.Errorf("validate compression: %w", err)
+	}
+
+	if viper.GetString("lockfile") == "" || viper.GetBool("dryrun") {
+		return copySources(ctx, client, sources, report, nil)
+	}
+
+	lockfile, err := manifest.GetLockfile(viper.GetString("lockfile"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("get lockfile: %w", err)
+	}
+
+	// The lockfile is also written when the copy fails, so that the digests
+	// of the images that were copied before the failure are not lost.
+	copyErr := copySources(ctx, client, sources, report, &lockfile)
+	if err := lockfile.Write(viper.GetString("lockfile")); err != nil {
+		if copyErr != nil {
+			log.WithError(err).Error("Unable to write lockfile")
+			return copyErr
+		}
+
+		return fmt.Errorf("write lockfile: %w", err)
+	}
+
+	return copyErr
+}
+
+// copySources copies the sources that do not exist at the target. When a lockfile is given,
+// the digest of each source is recorded in it once the source is copied, and sources that
+// already exist at the target are recorded when the lockfile has no digest for them.
+func copySources(ctx context.Context, client docker.Client, sources []manifest.Source, report *syncReport, lockfile *manifest.Lockfile) error {
 	log.Infof("Finding images that need to be copied ...")
 
+	failures := newSyncFailures()
+
 	var sourcesToCopy []manifest.Source
+	existingTargets := make(map[string]bool)
 	for _, source := range sources {
+		metrics.ImagesChecked.WithLabelValues(getMetricLabels(source)...).Inc()
+		image := imageReport{Source: source.Image(), Target: source.TargetImage()}
+
 		exists, err := client.ImageExistsAtRemote(ctx, source.TargetImage())
 		if err != nil {
-			return fmt.Errorf("image exists at remote: %w", err)
+			metrics.ImagesFailed.WithLabelValues(getMetricLabels(source)...).Inc()
+			report.add(image, actionFailed, "unable to check the target", err)
+			if err := failures.add(ctx, source.Image(), fmt.Errorf("image exists at remote: %w", err)); err != nil {
+				return err
+			}
+
+			continue
 		}
 
+		existingTargets[source.TargetImage()] = exists
 		if !exists || viper.GetBool("force") {
 			sourcesToCopy = append(sourcesToCopy, source)
+			continue
+		}
+
+		if err := syncExistingSource(ctx, client, source, lockfile); err != nil {
+			metrics.ImagesFailed.WithLabelValues(getMetricLabels(source)...).Inc()
+			report.add(image, actionFailed, "exists at target", err)
+			if err := failures.add(ctx, source.Image(), err); err != nil {
+				return err
+			}
+
+			continue
 		}
+
+		metrics.ImagesSkipped.WithLabelValues(getMetricLabels(source)...).Inc()
+		report.add(image, actionSkipped, "exists at target", nil)
 	}
 
 	if len(sourcesToCopy) == 0 {
+		if failures.failed() {
+			return failures.summary(len(sources))
+		}
+
+		metrics.LastSuccess.SetToCurrentTime()
 		log.Infof("All images are up to date!")
 		return nil
 	}
 
 	if viper.GetBool("dryrun") {
 		for _, source := range sourcesToCopy {
-			log.Infof("Image %s would be copied to %s", source.Image(), source.TargetImage())
+			sourceLogger(source, "copy").Info("Image would be copied")
+			report.add(imageReport{Source: source.Image(), Target: source.TargetImage()}, actionSkipped, "dry run", nil)
 		}
 
-		return nil
+		return failures.summary(len(sources))
 	}
 
-	// Create a default image policy accepting unsigned images.
-	policy := &signature.Policy{
-		Default: []signature.PolicyRequirement{
-			signature.NewPRInsecureAcceptAnything(),
-		},
-	}
-	policyContext, err := signature.NewPolicyContext(policy)
+	policyContext, err := newPolicyContext(sourcesToCopy)
 	if err != nil {
 		return fmt.Errorf("new policy context: %w", err)
 	}
+	defer policyContext.Destroy()
 
-	var copyOptions copy.Options
-	if viper.GetBool("all-variants") {
-		copyOptions.ImageListSelection = copy.CopyAllImages
-	} else {
-		copyOptions.ImageListSelection = copy.CopySystemImage
+	useSigstore := requiresSigstoreVerification(sourcesToCopy) || viper.GetString("sign-by-sigstore-key") != ""
+	registriesDir, cleanup, err := getRegistriesDir(useSigstore)
+	if err != nil {
+		return fmt.Errorf("get registries dir: %w", err)
 	}
+	defer cleanup()
 
-	if viper.GetString("override-os") != "" || viper.GetString("override-arch") != "" {
-		copyOptions.SourceCtx = &types.SystemContext{
-			ArchitectureChoice: viper.GetString("override-arch"),
-			OSChoice:           viper.GetString("override-os"),
-		}
+	copyOptions := newCopyOptions()
+	copyOptions.SourceCtx.RegistriesDirPath = registriesDir
+	copyOptions.DestinationCtx.RegistriesDirPath = registriesDir
+	if err := setSigningOptions(&copyOptions); err != nil {
+		return fmt.Errorf("set signing options: %w", err)
 	}
 
-	imageTransport := dockerv5.Transport
 	for _, source := range sourcesToCopy {
-		log.Infof("Copying image %s to %s", source.Image(), source.TargetImage())
-		destRef, err := imageTransport.ParseReference(fmt.Sprintf("//%s", source.TargetImage()))
-		if err != nil {
-			return fmt.Errorf("Error parsing target image reference: %w", err)
+		image := imageReport{Source: source.Image(), Target: source.TargetImage()}
+
+		reason := "missing at target"
+		if existingTargets[source.TargetImage()] {
+			reason = "forced"
+		}
+
+		imageCtx, cancel := newImageContext(ctx, getImageTimeout(source))
+
+		// The digest is resolved before the copy so that the lockfile records the digest
+		// that was copied, but is only recorded once the copy has succeeded.
+		var digest string
+		if lockfile != nil {
+			digest, err = client.GetDigest(imageCtx, source.Image())
+			if err != nil {
+				cancel()
+				metrics.ImagesFailed.WithLabelValues(getMetricLabels(source)...).Inc()
+				report.add(image, actionFailed, reason, err)
+				if err := failures.add(ctx, source.Image(), fmt.Errorf("get source digest: %w", err)); err != nil {
+					return err
+				}
+
+				continue
+			}
 		}
 
-		srcRef, err := imageTransport.ParseReference(fmt.Sprintf("//%s", source.Image()))
+		start := time.Now()
+		transferred, err := copySource(imageCtx, client, policyContext, copyOptions, source)
+		cancel()
+		image.Duration = time.Since(start).Seconds()
+		image.Bytes = transferred
 		if err != nil {
-			return fmt.Errorf("Error parsing source image reference: %w", err)
+			metrics.ImagesFailed.WithLabelValues(getMetricLabels(source)...).Inc()
+			report.add(image, actionFailed, reason, err)
+			if err := failures.add(ctx, source.Image(), err); err != nil {
+				return err
+			}
+
+			continue
 		}
 
-		if _, err := copy.Image(ctx, policyContext, destRef, srcRef, &copyOptions); err != nil {
-			return fmt.Errorf("copy image: %w", err)
+		if digest != "" {
+			lockfile.Set(source.Image(), digest)
 		}
+
+		metrics.ImagesCopied.WithLabelValues(getMetricLabels(source)...).Inc()
+		metrics.CopyDuration.WithLabelValues(getMetricLabels(source)...).Observe(image.Duration)
+		report.add(image, actionCopied, reason, nil)
 	}
 
+	if failures.failed() {
+		return failures.summary(len(sources))
+	}
+
+	metrics.LastSuccess.SetToCurrentTime()
 	log.Infof("All images have been copied!")
 	return nil
 }
+
+// syncExistingSource records the digest of a source that already exists at the target in the
+// lockfile when it has no digest for it, and copies the artifacts attached to the image when
+// include-artifacts is set, as artifacts can be attached after the image has been copied.
+func syncExistingSource(ctx context.Context, client docker.Client, source manifest.Source, lockfile *manifest.Lockfile) error {
+	if viper.GetBool("dryrun") {
+		return nil
+	}
+
+	imageCtx, cancel := newImageContext(ctx, getImageTimeout(source))
+	defer cancel()
+
+	if lockfile != nil {
+		if _, exists := lockfile.Digest(source.Image()); !exists {
+			digest, err := client.GetDigest(imageCtx, source.Image())
+			if err != nil {
+				return fmt.Errorf("get source digest: %w", err)
+			}
+
+			lockfile.Set(source.Image(), digest)
+		}
+	}
+
+	if viper.GetBool("include-artifacts") && !source.IsArtifact() {
+		if err := copyAttachedArtifacts(imageCtx, client, source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copySource copies the image or artifact of the source to its target, and returns
+// the number of bytes of the layers of the image that were transferred.
+func copySource(ctx context.Context, client docker.Client, policyContext *signature.PolicyContext, copyOptions copy.Options, source manifest.Source) (int64, error) {
+	// Artifacts are not container images, so they are copied byte for byte
+	// and are not recompressed or signed.
+	if source.IsArtifact() {
+		sourceLogger(source, "copy").Info("Copying artifact")
+
+		if err := client.CopyArtifact(ctx, source.Image(), source.TargetImage(), source.ArtifactType); err != nil {
+			return 0, fmt.Errorf("copy artifact: %w", err)
+		}
+
+		return 0, nil
+	}
+
+	sourceLogger(source, "copy").Info("Copying image")
+	destRef, err := dockerv5.Transport.ParseReference(fmt.Sprintf("//%s", source.TargetImage()))
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing target image reference: %w", err)
+	}
+
+	srcRef, err := dockerv5.Transport.ParseReference(fmt.Sprintf("//%s", source.Image()))
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing source image reference: %w", err)
+	}
+
+	sourceCopyOptions, err := getCompressionCopyOptions(copyOptions, getCompressionFormat(source))
+	if err != nil {
+		return 0, fmt.Errorf("get compression options: %w", err)
+	}
+
+	progress := make(chan types.ProgressProperties)
+	transferred := make(chan int64)
+	go func() {
+		transferred <- recordBytesTransferred(progress, source)
+	}()
+
+	sourceCopyOptions.Progress = progress
+	sourceCopyOptions.ProgressInterval = time.Second
+
+	_, err = copy.Image(ctx, policyContext, destRef, srcRef, &sourceCopyOptions)
+	close(progress)
+
+	bytes := <-transferred
+	if err != nil {
+		return bytes, fmt.Errorf("copy image: %w", err)
+	}
+
+	if viper.GetBool("include-artifacts") {
+		if err := copyAttachedArtifacts(ctx, client, source); err != nil {
+			return bytes, err
+		}
+	}
+
+	return bytes, nil
+}
+
+// copyAttachedArtifacts copies the artifacts attached to the image of the source to its target.
+// The artifacts are skipped when the digest of the target differs from the source, such as an
+// image copied before include-artifacts was set, as they would not verify against the target.
+func copyAttachedArtifacts(ctx context.Context, client docker.Client, source manifest.Source) error {
+	sourceDigest, err := client.GetDigest(ctx, source.Image())
+	if err != nil {
+		return fmt.Errorf("get source digest: %w", err)
+	}
+
+	targetDigest, err := client.GetDigest(ctx, source.TargetImage())
+	if err != nil {
+		return fmt.Errorf("get target digest: %w", err)
+	}
+
+	if sourceDigest != targetDigest {
+		sourceLogger(source, "artifacts").WithFields(log.Fields{"sourceDigest": sourceDigest, "targetDigest": targetDigest}).Warn("Digest of the target differs from the source, so the attached artifacts would not verify against it. Skipping ...")
+		return nil
+	}
+
+	copied, err := client.CopyAttachedArtifacts(ctx, source.Image(), source.TargetImage())
+	if err != nil {
+		return fmt.Errorf("copy attached artifacts: %w", err)
+	}
+
+	if copied > 0 {
+		sourceLogger(source, "artifacts").WithField("artifacts", copied).Info("Copied the artifacts attached to the image")
+	}
+
+	return nil
+}
+
+// recordBytesTransferred records the bytes read from the source for each progress update
+// of the copy, and returns the total number of bytes once the progress channel is closed.
+func recordBytesTransferred(progress <-chan types.ProgressProperties, source manifest.Source) int64 {
+	var total int64
+	for properties := range progress {
+		if properties.Event == types.ProgressEventRead || properties.Event == types.ProgressEventDone {
+			metrics.BytesTransferred.WithLabelValues(getMetricLabels(source)...).Add(float64(properties.OffsetUpdate))
+			total += int64(properties.OffsetUpdate)
+		}
+	}
+
+	return total
+}
+
+// newCopyOptions returns the options used to copy images, configured
+// by the variant flags (all-variants, override-arch and override-os).
+//
+// The artifacts attached to an image reference the digest of the source image, so when
+// include-artifacts is set every variant is copied and the digest of the image is preserved.
+func newCopyOptions() copy.Options {
+	copyOptions := copy.Options{
+		SourceCtx: &types.SystemContext{
+			ArchitectureChoice: viper.GetString("override-arch"),
+			OSChoice:           viper.GetString("override-os"),
+		},
+		DestinationCtx: &types.SystemContext{},
+	}
+
+	if viper.GetBool("all-variants") || viper.GetBool("include-artifacts") {
+		copyOptions.ImageListSelection = copy.CopyAllImages
+	} else {
+		copyOptions.ImageListSelection = copy.CopySystemImage
+	}
+
+	copyOptions.PreserveDigests = viper.GetBool("include-artifacts")
+
+	return copyOptions
+}
+
+// getCompressionFormat returns the compression format of the layers written to
+// the target for the given source. The compression of the source takes precedence
+// over the compression of its target, which takes precedence over the flag.
+func getCompressionFormat(source manifest.Source) string {
+	if source.Compression != "" {
+		return source.Compression
+	}
+
+	if source.Target.Compression != "" {
+		return source.Target.Compression
+	}
+
+	return viper.GetString("compression-format")
+}
+
+// getCompressionCopyOptions returns a copy of the options that recompresses the
+// layers written to the target using the given compression format. Layers are not
+// recompressed when the digests are preserved, as that would change the digest.
+func getCompressionCopyOptions(copyOptions copy.Options, format string) (copy.Options, error) {
+	if format == "" || copyOptions.PreserveDigests {
+		return copyOptions, nil
+	}
+
+	algorithm, err := compression.AlgorithmByName(format)
+	if err != nil {
+		return copy.Options{}, fmt.Errorf("compression algorithm: %w", err)
+	}
+
+	destinationCtx := *copyOptions.DestinationCtx
+	destinationCtx.CompressionFormat = &algorithm
+	if viper.GetInt("compression-level") != 0 {
+		level := viper.GetInt("compression-level")
+		destinationCtx.CompressionLevel = &level
+	}
+
+	copyOptions.DestinationCtx = &destinationCtx
+	return copyOptions, nil
+}
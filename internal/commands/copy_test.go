@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/types"
+	"github.com/spf13/viper"
+)
+
+func TestGetCompressionFormat(t *testing.T) {
+	testCases := []struct {
+		source   manifest.Source
+		expected string
+	}{
+		{
+			manifest.Source{},
+			"",
+		},
+		{
+			manifest.Source{Target: manifest.Target{Compression: "zstd"}},
+			"zstd",
+		},
+		{
+			manifest.Source{Compression: "gzip", Target: manifest.Target{Compression: "zstd"}},
+			"gzip",
+		},
+	}
+
+	for _, testCase := range testCases {
+		actual := getCompressionFormat(testCase.source)
+		if actual != testCase.expected {
+			t.Errorf("expected compression format %s, actual %s", testCase.expected, actual)
+		}
+	}
+}
+
+func TestGetCompressionCopyOptions(t *testing.T) {
+	copyOptions := copy.Options{
+		DestinationCtx: &types.SystemContext{},
+	}
+
+	actual, err := getCompressionCopyOptions(copyOptions, "zstd:chunked")
+	if err != nil {
+		t.Fatal("get compression copy options:", err)
+	}
+
+	if actual.DestinationCtx.CompressionFormat == nil || actual.DestinationCtx.CompressionFormat.Name() != "zstd:chunked" {
+		t.Errorf("expected compression format to be zstd:chunked")
+	}
+
+	if copyOptions.DestinationCtx.CompressionFormat != nil {
+		t.Errorf("expected original copy options to not be modified")
+	}
+
+	if _, err := getCompressionCopyOptions(copyOptions, "invalid"); err == nil {
+		t.Errorf("expected invalid compression format to return an error")
+	}
+}
+
+func TestValidateCopyFlags(t *testing.T) {
+	defer viper.Reset()
+
+	viper.Set("include-artifacts", true)
+	if err := validateCopyFlags(); err != nil {
+		t.Errorf("expected include-artifacts to be valid, actual %s", err)
+	}
+
+	viper.Set("override-arch", "arm64")
+	if err := validateCopyFlags(); err == nil {
+		t.Errorf("expected include-artifacts with override-arch to return an error")
+	}
+
+	viper.Set("override-arch", "")
+	viper.Set("compression-format", "zstd")
+	if err := validateCopyFlags(); err == nil {
+		t.Errorf("expected include-artifacts with compression-format to return an error")
+	}
+}
+
+func TestValidateSourceCompression(t *testing.T) {
+	sources := []manifest.Source{
+		{Repository: "busybox", Tag: "1.0.0", Compression: "zstd:chunked", Target: manifest.Target{Compression: "gzip"}},
+	}
+	if err := validateSourceCompression(sources); err != nil {
+		t.Errorf("expected valid compression, actual %s", err)
+	}
+
+	for _, format := range []string{"bzip2", "xz", "invalid"} {
+		invalidSource := []manifest.Source{{Repository: "busybox", Tag: "1.0.0", Target: manifest.Target{Compression: format}}}
+		if err := validateSourceCompression(invalidSource); err == nil {
+			t.Errorf("expected compression %s to return an error", format)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package commands
 
 import (
+	"fmt"
 	"os"
 	"path"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -18,21 +20,54 @@ func NewDefaultCommand() *cobra.Command {
 		Short:   "sinker",
 		Long:    "A tool to sync container images to another container registry",
 		Version: sinkerVersion,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := configureLogging(); err != nil {
+				return fmt.Errorf("configure logging: %w", err)
+			}
+
+			return nil
+		},
 	}
 
 	cmd.PersistentFlags().StringP("manifest", "m", "", "Path where the manifest file is (defaults to .images.yaml in the current directory)")
 	viper.BindPFlag("manifest", cmd.PersistentFlags().Lookup("manifest"))
 
+	cmd.PersistentFlags().Duration("timeout", 30*time.Minute, "Maximum time the command can take to run (0 to disable)")
+	viper.BindPFlag("timeout", cmd.PersistentFlags().Lookup("timeout"))
+
+	cmd.PersistentFlags().Duration("image-timeout", 0, "Maximum time a single image can take to be copied, pushed, pulled, verified or checked (0 to disable)")
+	viper.BindPFlag("image-timeout", cmd.PersistentFlags().Lookup("image-timeout"))
+
+	cmd.PersistentFlags().Duration("api-timeout", 0, "Maximum time to wait for the response headers of the registry API requests sinker makes itself, such as checking, listing and resolving images, but not of the image copies (0 to disable)")
+	viper.BindPFlag("api-timeout", cmd.PersistentFlags().Lookup("api-timeout"))
+
+	cmd.PersistentFlags().String("log-format", "text", "Format of the logs (text or json)")
+	viper.BindPFlag("log-format", cmd.PersistentFlags().Lookup("log-format"))
+
+	cmd.PersistentFlags().String("log-level", "info", "Level of the logs (debug, info, warn or error)")
+	viper.BindPFlag("log-level", cmd.PersistentFlags().Lookup("log-level"))
+
+	cmd.PersistentFlags().BoolP("quiet", "q", false, "Only log errors")
+	viper.BindPFlag("quiet", cmd.PersistentFlags().Lookup("quiet"))
+
 	viper.SetEnvPrefix("SINKER")
 	viper.AutomaticEnv()
 
 	cmd.AddCommand(newCreateCommand())
 	cmd.AddCommand(newUpdateCommand())
+	cmd.AddCommand(newRewriteCommand())
 	cmd.AddCommand(newListCommand())
 	cmd.AddCommand(newPullCommand())
 	cmd.AddCommand(newPushCommand())
 	cmd.AddCommand(newCopyCommand())
+	cmd.AddCommand(newWatchCommand())
+	cmd.AddCommand(newExportCommand())
+	cmd.AddCommand(newImportCommand())
+	cmd.AddCommand(newVerifyCommand())
+	cmd.AddCommand(newPruneCommand())
 	cmd.AddCommand(newCheckCommand())
+	cmd.AddCommand(newGenerateCommand())
+	cmd.AddCommand(newServeCommand())
 	cmd.AddCommand(newVersionCommand())
 
 	return &cmd
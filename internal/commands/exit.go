@@ -0,0 +1,24 @@
+package commands
+
+const (
+	// ExitCodeOutdated is the exit code when images have newer versions
+	// that exceed the threshold set by the fail-on flag.
+	ExitCodeOutdated = 2
+
+	// ExitCodeErrored is the exit code when one or more images could not be checked.
+	ExitCodeErrored = 3
+)
+
+// ExitError is an error that should exit the process with the given exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
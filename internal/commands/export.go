@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	"github.com/containers/image/v5/copy"
+	dockerv5 "github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/oci/layout"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newExportCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "export",
+		Short: "Export the images in the manifest to an archive or OCI layout for air-gapped environments",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			flags := []string{"output", "override-arch", "override-os", "all-variants", "policy", "registries-dir"}
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runExportCommand(); err != nil {
+				return fmt.Errorf("export: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("output", "", "Path of the tar archive (e.g. bundle.tar) or OCI layout directory to export to")
+	cmd.MarkFlagRequired("output")
+
+	cmd.Flags().StringP("override-arch", "a", "", "Architecture variant of the image if it is a multi-arch image")
+	cmd.Flags().StringP("override-os", "o", "", "Operating system variant of the image if it is a multi-os image")
+	cmd.Flags().Bool("all-variants", false, "Export all variants of the image")
+	cmd.Flags().String("policy", "", "Path to a containers-policy.json file the source images must meet (defaults to accepting unsigned images)")
+	cmd.Flags().String("registries-dir", "", "Path to a registries.d directory configuring where signatures are stored")
+
+	return &cmd
+}
+
+func runExportCommand() error {
+	ctx, cancel := newTimeoutContext(context.Background())
+	defer cancel()
+
+	manifestPath := viper.GetString("manifest")
+	imageManifest, err := manifest.Get(manifestPath)
+	if err != nil {
+		return fmt.Errorf("get manifest: %w", err)
+	}
+
+	// When exporting to an archive, the OCI layout is first written to a temporary
+	// directory which is then archived once all of the images have been exported.
+	outputPath := viper.GetString("output")
+	layoutPath := outputPath
+	if isArchivePath(outputPath) {
+		layoutPath, err = os.MkdirTemp("", "sinker-export")
+		if err != nil {
+			return fmt.Errorf("create temp dir: %w", err)
+		}
+		defer os.RemoveAll(layoutPath)
+	} else if err := os.MkdirAll(layoutPath, os.ModePerm); err != nil {
+		return fmt.Errorf("create layout dir: %w", err)
+	}
+
+	policyContext, err := newPolicyContext(imageManifest.Sources)
+	if err != nil {
+		return fmt.Errorf("new policy context: %w", err)
+	}
+	defer policyContext.Destroy()
+
+	registriesDir, cleanup, err := getRegistriesDir(requiresSigstoreVerification(imageManifest.Sources))
+	if err != nil {
+		return fmt.Errorf("get registries dir: %w", err)
+	}
+	defer cleanup()
+
+	copyOptions := newCopyOptions()
+	copyOptions.SourceCtx.RegistriesDirPath = registriesDir
+	copyOptions.DestinationCtx.RegistriesDirPath = registriesDir
+
+	for _, source := range imageManifest.Sources {
+		sourceLogger(source, "export").Info("Exporting image")
+
+		srcRef, err := dockerv5.Transport.ParseReference(fmt.Sprintf("//%s", source.Image()))
+		if err != nil {
+			return fmt.Errorf("parse source image reference: %w", err)
+		}
+
+		// The source image is used as the name of the image in the layout
+		// so that it can be matched to the source in the manifest on import.
+		destRef, err := layout.NewReference(layoutPath, source.Image())
+		if err != nil {
+			return fmt.Errorf("new layout reference: %w", err)
+		}
+
+		if _, err := copy.Image(ctx, policyContext, destRef, srcRef, &copyOptions); err != nil {
+			return fmt.Errorf("copy image: %w", err)
+		}
+	}
+
+	manifestContents, err := os.ReadFile(manifest.Location(manifestPath))
+	if err != nil {
+		return fmt.Errorf("read manifest: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(layoutPath, ".images.yaml"), manifestContents, os.ModePerm); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	if isArchivePath(outputPath) {
+		if err := writeArchive(layoutPath, outputPath); err != nil {
+			return fmt.Errorf("write archive: %w", err)
+		}
+	}
+
+	log.WithField("path", outputPath).Info("All images have been exported!")
+	return nil
+}
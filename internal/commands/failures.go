@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// failureFlags are the flags that decide whether a sync stops at the first failed image.
+var failureFlags = []string{"keep-going", "fail-fast"}
+
+func addFailureFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("keep-going", false, "Continue with the remaining images when an image fails, and exit with an error once all images are processed")
+	cmd.Flags().Bool("fail-fast", false, "Stop at the first image that fails (default)")
+}
+
+func validateFailureFlags() error {
+	if viper.GetBool("keep-going") && viper.GetBool("fail-fast") {
+		return errors.New("keep-going and fail-fast cannot be used together")
+	}
+
+	return nil
+}
+
+// imageFailure is an image that failed to sync, and the error it failed with.
+type imageFailure struct {
+	Image string
+	Err   error
+}
+
+// syncFailures are the images that failed during a sync. When keep going, a failed
+// image is recorded and the sync continues, otherwise the sync stops at the failure.
+type syncFailures struct {
+	keepGoing bool
+	images    []imageFailure
+}
+
+func newSyncFailures() *syncFailures {
+	failures := syncFailures{
+		keepGoing: viper.GetBool("keep-going"),
+	}
+
+	return &failures
+}
+
+// add records the failure of the image. The error is returned when the sync should stop,
+// and nil is returned when the sync should continue with the next image. An image that
+// timed out is always recorded and the sync continues, as long as the context of the
+// command has not been cancelled, so that a single slow image does not stop the sync.
+func (f *syncFailures) add(ctx context.Context, image string, err error) error {
+	if !f.keepGoing && !isImageTimeout(ctx, err) {
+		return err
+	}
+
+	log.WithField("image", image).WithError(err).Error("Image failed. Continuing ...")
+	f.images = append(f.images, imageFailure{Image: image, Err: err})
+
+	return nil
+}
+
+// isImageTimeout returns true if the error was caused by the timeout of an image or
+// of a request to a registry, rather than by the context of the command.
+func isImageTimeout(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (f *syncFailures) failed() bool {
+	return len(f.images) > 0
+}
+
+// summary logs each of the failed images, and returns an error when any image failed.
+func (f *syncFailures) summary(total int) error {
+	if !f.failed() {
+		return nil
+	}
+
+	log.WithFields(log.Fields{"failed": len(f.images), "total": total}).Error("Images failed")
+	for _, failure := range f.images {
+		log.WithField("image", failure.Image).WithError(failure.Err).Error("Image failed")
+	}
+
+	return fmt.Errorf("%d of %d images failed", len(f.images), total)
+}
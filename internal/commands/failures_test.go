@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSyncFailures(t *testing.T) {
+	ctx := context.Background()
+
+	failFast := &syncFailures{}
+	if err := failFast.add(ctx, "busybox:1.0.0", errors.New("unauthorized")); err == nil {
+		t.Errorf("expected fail fast to return the error, actual nil")
+	}
+
+	timeout := fmt.Errorf("copy image: %w", context.DeadlineExceeded)
+	if err := failFast.add(ctx, "busybox:1.0.0", timeout); err != nil {
+		t.Errorf("expected fail fast to continue after an image timeout, actual %s", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := failFast.add(cancelled, "busybox:1.0.0", timeout); err == nil {
+		t.Errorf("expected fail fast to return the error once the command is cancelled, actual nil")
+	}
+
+	keepGoing := &syncFailures{keepGoing: true}
+	if err := keepGoing.add(ctx, "busybox:1.0.0", errors.New("unauthorized")); err != nil {
+		t.Errorf("expected keep going to continue, actual %s", err)
+	}
+
+	expected := "1 of 3 images failed"
+	if err := keepGoing.summary(3); err == nil || err.Error() != expected {
+		t.Errorf("expected summary %s, actual %v", expected, err)
+	}
+
+	if err := (&syncFailures{keepGoing: true}).summary(3); err != nil {
+		t.Errorf("expected no error when no images failed, actual %s", err)
+	}
+}
@@ -0,0 +1,269 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newGenerateCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "generate <config>",
+		Short: "Generate configuration from the manifest",
+	}
+
+	cmd.AddCommand(newGenerateRegistryConfigCommand())
+
+	return &cmd
+}
+
+func newGenerateRegistryConfigCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "registry-config",
+		Short: "Generate the configuration of a container runtime that mirrors the sources to the target",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			flags := []string{"format", "output"}
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			if !contains([]string{"containerd", "crio", "docker"}, viper.GetString("format")) {
+				return fmt.Errorf("unknown format %s (must be containerd, crio or docker)", viper.GetString("format"))
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runGenerateRegistryConfigCommand(); err != nil {
+				return fmt.Errorf("generate registry config: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("format", "", "Container runtime to generate the configuration for (containerd, crio or docker)")
+	cmd.Flags().StringP("output", "o", "", "Path to write the configuration to (defaults to stdout, and is the certs.d directory for containerd)")
+
+	cmd.MarkFlagRequired("format")
+
+	return &cmd
+}
+
+func runGenerateRegistryConfigCommand() error {
+	imageManifest, err := manifest.Get(viper.GetString("manifest"))
+	if err != nil {
+		return fmt.Errorf("get manifest: %w", err)
+	}
+
+	output := viper.GetString("output")
+
+	var config string
+	switch viper.GetString("format") {
+	case "containerd":
+		if output == "" {
+			return errors.New("containerd requires an output directory to write the hosts.toml files to")
+		}
+
+		mirrors, err := getRegistryMirrors(imageManifest.Sources)
+		if err != nil {
+			return fmt.Errorf("get registry mirrors: %w", err)
+		}
+
+		for _, host := range getSortedHosts(mirrors) {
+			location := mirrors[host]
+
+			hostsPath := filepath.Join(output, host, "hosts.toml")
+			if err := os.MkdirAll(filepath.Dir(hostsPath), os.ModePerm); err != nil {
+				return fmt.Errorf("create host dir: %w", err)
+			}
+
+			if err := os.WriteFile(hostsPath, []byte(getContainerdHostsConfig(host, location)), os.ModePerm); err != nil {
+				return fmt.Errorf("write hosts file: %w", err)
+			}
+
+			log.WithFields(log.Fields{"host": host, "path": hostsPath}).Info("Wrote mirror of host")
+		}
+
+		return nil
+	case "crio":
+		config = getCrioRegistriesConfig(imageManifest.Sources)
+	case "docker":
+		config, err = getDockerDaemonConfig(imageManifest.Sources)
+		if err != nil {
+			return fmt.Errorf("get docker daemon config: %w", err)
+		}
+	}
+
+	if output == "" {
+		fmt.Print(config)
+		return nil
+	}
+
+	if err := os.WriteFile(output, []byte(config), os.ModePerm); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+
+	return nil
+}
+
+// getRegistryMirrors returns the location in the target registry that each source host
+// is mirrored to. The sources of targets that do not support nested repositories are
+// skipped, as their repositories are flattened and cannot be mirrored by host.
+//
+// Official images of Docker Hub are requested from the library namespace by the runtimes
+// that mirror by host, so an official image that is not mirrored under library is an error.
+func getRegistryMirrors(sources []manifest.Source) (map[string]string, error) {
+	mirrors := make(map[string]string)
+	for _, source := range sources {
+		if source.IsArtifact() {
+			continue
+		}
+
+		if !source.Target.SupportsNestedRepositories() {
+			sourceLogger(source, "generate").Info("Image cannot be mirrored by host as the target does not support nested repositories. Skipping ...")
+			continue
+		}
+
+		host := getSourceHost(source)
+		if host == "docker.io" && !strings.Contains(source.Repository, "/") {
+			return nil, fmt.Errorf("official image %s would be requested from library/%s at the mirror, set the repository of the source to library/%s", source.Image(), source.Repository, source.Repository)
+		}
+
+		location := strings.TrimSuffix(source.Target.Host+"/"+source.Target.Repository, "/")
+		if existingLocation, exists := mirrors[host]; exists && existingLocation != location {
+			return nil, fmt.Errorf("host %s is mirrored to both %s and %s", host, existingLocation, location)
+		}
+
+		mirrors[host] = location
+	}
+
+	return mirrors, nil
+}
+
+// getContainerdHostsConfig returns the hosts.toml of the source host that pulls
+// the images of the host from the given location in the target registry.
+func getContainerdHostsConfig(host string, location string) string {
+	server := "https://" + host
+	if host == "docker.io" {
+		server = "https://registry-1.docker.io"
+	}
+
+	var config strings.Builder
+	fmt.Fprintf(&config, "server = %q\n\n", server)
+
+	// The path of a mirror is only used when it is overridden, in
+	// which case the path must include the /v2 prefix of the API.
+	targetHost, repository, _ := strings.Cut(location, "/")
+	if repository == "" {
+		fmt.Fprintf(&config, "[host.%q]\n", "https://"+targetHost)
+		fmt.Fprintf(&config, "  capabilities = [\"pull\", \"resolve\"]\n")
+	} else {
+		fmt.Fprintf(&config, "[host.%q]\n", "https://"+targetHost+"/v2/"+repository)
+		fmt.Fprintf(&config, "  capabilities = [\"pull\", \"resolve\"]\n")
+		fmt.Fprintf(&config, "  override_path = true\n")
+	}
+
+	return config.String()
+}
+
+// getCrioRegistriesConfig returns a registries.conf that mirrors each source repository
+// to its target repository. Unlike the other runtimes, the mirrors are configured for
+// each repository, so targets that do not support nested repositories are supported.
+func getCrioRegistriesConfig(sources []manifest.Source) string {
+	var config strings.Builder
+	prefixes := make(map[string]bool)
+	for _, source := range sources {
+		if source.IsArtifact() {
+			continue
+		}
+
+		// Images of Docker Hub without a namespace are pulled from the library namespace.
+		repository := source.Repository
+		if getSourceHost(source) == "docker.io" && !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+
+		prefix := getSourceHost(source) + "/" + repository
+		if prefixes[prefix] {
+			continue
+		}
+
+		prefixes[prefix] = true
+		location, _ := splitImageTag(source.TargetImage())
+
+		if config.Len() > 0 {
+			config.WriteString("\n")
+		}
+
+		fmt.Fprintf(&config, "[[registry]]\n")
+		fmt.Fprintf(&config, "prefix = %q\n", prefix)
+		fmt.Fprintf(&config, "location = %q\n\n", prefix)
+		fmt.Fprintf(&config, "[[registry.mirror]]\n")
+		fmt.Fprintf(&config, "location = %q\n", location)
+	}
+
+	return config.String()
+}
+
+// getDockerDaemonConfig returns a daemon.json with the registry mirror of Docker Hub.
+// The Docker daemon only supports mirrors of Docker Hub at the root of a registry.
+func getDockerDaemonConfig(sources []manifest.Source) (string, error) {
+	mirrors, err := getRegistryMirrors(sources)
+	if err != nil {
+		return "", fmt.Errorf("get registry mirrors: %w", err)
+	}
+
+	location, exists := mirrors["docker.io"]
+	if !exists {
+		return "", errors.New("no images of docker hub are mirrored to a registry that supports nested repositories")
+	}
+
+	if strings.Contains(location, "/") {
+		return "", fmt.Errorf("docker hub is mirrored to %s, but the docker daemon only supports mirrors at the root of a registry", location)
+	}
+
+	daemonConfig := map[string][]string{
+		"registry-mirrors": {"https://" + location},
+	}
+
+	contents, err := json.MarshalIndent(daemonConfig, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal daemon config: %w", err)
+	}
+
+	return string(contents) + "\n", nil
+}
+
+// getSourceHost returns the host of the source, where an empty host is Docker Hub.
+func getSourceHost(source manifest.Source) string {
+	if source.Host == "" {
+		return "docker.io"
+	}
+
+	return source.Host
+}
+
+// getSortedHosts returns the hosts of the mirrors in a stable order.
+func getSortedHosts(mirrors map[string]string) []string {
+	var hosts []string
+	for host := range mirrors {
+		hosts = append(hosts, host)
+	}
+
+	sort.Strings(hosts)
+	return hosts
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+)
+
+func TestGetRegistryMirrors(t *testing.T) {
+	sources := []manifest.Source{
+		{Repository: "library/busybox", Tag: "1.0.0", Target: manifest.Target{Host: "mycr.com", Repository: "mirror"}},
+		{Repository: "coreos/prometheus-operator", Host: "quay.io", Tag: "v0.40.0", Target: manifest.Target{Host: "mycr.com", Repository: "mirror"}},
+		{Repository: "app/app", Host: "ghcr.io", Tag: "1.0.0", Target: manifest.Target{Host: "quay.io", Repository: "mirror"}},
+	}
+
+	actual, err := getRegistryMirrors(sources)
+	if err != nil {
+		t.Fatal("get registry mirrors:", err)
+	}
+
+	expected := map[string]string{
+		"docker.io": "mycr.com/mirror",
+		"quay.io":   "mycr.com/mirror",
+	}
+
+	if len(actual) != len(expected) {
+		t.Fatalf("expected mirrors %v, actual %v", expected, actual)
+	}
+
+	for host, location := range expected {
+		if actual[host] != location {
+			t.Errorf("expected %s to be mirrored to %s, actual %s", host, location, actual[host])
+		}
+	}
+
+	sources = append(sources, manifest.Source{Repository: "library/alpine", Tag: "3.0.0", Target: manifest.Target{Host: "othercr.com"}})
+	if _, err := getRegistryMirrors(sources); err == nil {
+		t.Error("expected an error when a host is mirrored to more than one location")
+	}
+}
+
+func TestGetRegistryMirrorsOfficialImage(t *testing.T) {
+	sources := []manifest.Source{
+		{Repository: "busybox", Tag: "1.0.0", Target: manifest.Target{Host: "mycr.com", Repository: "mirror"}},
+	}
+
+	if _, err := getRegistryMirrors(sources); err == nil {
+		t.Error("expected an error when an official image is not mirrored under library")
+	}
+}
+
+func TestGetContainerdHostsConfig(t *testing.T) {
+	expected := `server = "https://registry-1.docker.io"
+
+[host."https://mycr.com/v2/mirror"]
+  capabilities = ["pull", "resolve"]
+  override_path = true
+`
+
+	actual := getContainerdHostsConfig("docker.io", "mycr.com/mirror")
+	if actual != expected {
+		t.Errorf("expected hosts config %s, actual %s", expected, actual)
+	}
+}
+
+func TestGetCrioRegistriesConfig(t *testing.T) {
+	sources := []manifest.Source{
+		{Repository: "busybox", Tag: "1.0.0", Target: manifest.Target{Host: "mycr.com", Repository: "mirror"}},
+		{Repository: "busybox", Tag: "1.1.0", Target: manifest.Target{Host: "mycr.com", Repository: "mirror"}},
+		{Repository: "coreos/prometheus-operator", Host: "quay.io", Tag: "v0.40.0", Target: manifest.Target{Host: "docker.io", Repository: "myteam"}},
+	}
+
+	expected := `[[registry]]
+prefix = "docker.io/library/busybox"
+location = "docker.io/library/busybox"
+
+[[registry.mirror]]
+location = "mycr.com/mirror/busybox"
+
+[[registry]]
+prefix = "quay.io/coreos/prometheus-operator"
+location = "quay.io/coreos/prometheus-operator"
+
+[[registry.mirror]]
+location = "docker.io/myteam/prometheus-operator"
+`
+
+	actual := getCrioRegistriesConfig(sources)
+	if actual != expected {
+		t.Errorf("expected registries config %s, actual %s", expected, actual)
+	}
+}
+
+func TestGetDockerDaemonConfig(t *testing.T) {
+	sources := []manifest.Source{
+		{Repository: "library/busybox", Tag: "1.0.0", Target: manifest.Target{Host: "mycr.com"}},
+	}
+
+	actual, err := getDockerDaemonConfig(sources)
+	if err != nil {
+		t.Fatal("get docker daemon config:", err)
+	}
+
+	expected := `{
+  "registry-mirrors": [
+    "https://mycr.com"
+  ]
+}
+`
+
+	if actual != expected {
+		t.Errorf("expected daemon config %s, actual %s", expected, actual)
+	}
+
+	sources[0].Target.Repository = "mirror"
+	if _, err := getDockerDaemonConfig(sources); err == nil {
+		t.Error("expected an error when docker hub is not mirrored to the root of a registry")
+	}
+}
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/plexsystems/sinker/internal/docker"
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	"github.com/containers/image/v5/copy"
+	dockerv5 "github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/oci/layout"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newImportCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "import <bundle>",
+		Short: "Import the images in an exported archive or OCI layout to the target repository",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			flags := []string{"dryrun", "target", "force"}
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runImportCommand(args[0]); err != nil {
+				return fmt.Errorf("import: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("dryrun", false, "Print a list of images that would be imported to the target")
+	cmd.Flags().StringP("target", "t", "", "Registry the images will be imported to (ignores the manifest and imports every image in the bundle)")
+	cmd.Flags().Bool("force", false, "Force the import of the image even if already exists at the target")
+
+	return &cmd
+}
+
+func runImportCommand(bundlePath string) error {
+	ctx, cancel := newTimeoutContext(context.Background())
+	defer cancel()
+
+	client, err := newDockerClient()
+	if err != nil {
+		return fmt.Errorf("new client: %w", err)
+	}
+
+	layoutPath := bundlePath
+	if isArchivePath(bundlePath) {
+		layoutPath, err = os.MkdirTemp("", "sinker-import")
+		if err != nil {
+			return fmt.Errorf("create temp dir: %w", err)
+		}
+		defer os.RemoveAll(layoutPath)
+
+		if err := extractArchive(bundlePath, layoutPath); err != nil {
+			return fmt.Errorf("extract archive: %w", err)
+		}
+	}
+
+	bundleImages, err := docker.GetImagesInLayout(layoutPath)
+	if err != nil {
+		return fmt.Errorf("get images in layout: %w", err)
+	}
+
+	// When a target is given, every image in the bundle is imported. Otherwise the
+	// manifest passed to the command, or the manifest embedded in the bundle, is used.
+	var sources []manifest.Source
+	if viper.GetString("target") != "" {
+		sources = manifest.GetSourcesFromImages(bundleImages, viper.GetString("target"))
+	} else {
+		manifestPath := viper.GetString("manifest")
+		if manifestPath == "" {
+			manifestPath = layoutPath
+		}
+
+		imageManifest, err := manifest.Get(manifestPath)
+		if err != nil {
+			return fmt.Errorf("get manifest: %w", err)
+		}
+
+		sources = imageManifest.Sources
+	}
+
+	if len(sources) == 0 {
+		return errors.New("no images found to import")
+	}
+
+	log.Infof("Finding images that need to be imported ...")
+
+	var sourcesToImport []manifest.Source
+	for _, source := range sources {
+		if !contains(bundleImages, source.Image()) {
+			return fmt.Errorf("image %s not found in bundle", source.Image())
+		}
+
+		exists, err := client.ImageExistsAtRemote(ctx, source.TargetImage())
+		if err != nil {
+			return fmt.Errorf("image exists at remote: %w", err)
+		}
+
+		if !exists || viper.GetBool("force") {
+			sourcesToImport = append(sourcesToImport, source)
+		}
+	}
+
+	if len(sourcesToImport) == 0 {
+		log.Infof("All images are up to date!")
+		return nil
+	}
+
+	if viper.GetBool("dryrun") {
+		for _, source := range sourcesToImport {
+			sourceLogger(source, "import").Info("Image would be imported")
+		}
+
+		return nil
+	}
+
+	policyContext, err := newPolicyContext(nil)
+	if err != nil {
+		return fmt.Errorf("new policy context: %w", err)
+	}
+	defer policyContext.Destroy()
+
+	// The platforms were chosen when the bundle was exported, so every
+	// variant that exists in the bundle is imported.
+	copyOptions := copy.Options{
+		ImageListSelection: copy.CopyAllImages,
+	}
+
+	for _, source := range sourcesToImport {
+		sourceLogger(source, "import").Info("Importing image")
+
+		srcRef, err := layout.NewReference(layoutPath, source.Image())
+		if err != nil {
+			return fmt.Errorf("new layout reference: %w", err)
+		}
+
+		destRef, err := dockerv5.Transport.ParseReference(fmt.Sprintf("//%s", source.TargetImage()))
+		if err != nil {
+			return fmt.Errorf("parse target image reference: %w", err)
+		}
+
+		if _, err := copy.Image(ctx, policyContext, destRef, srcRef, &copyOptions); err != nil {
+			return fmt.Errorf("copy image: %w", err)
+		}
+	}
+
+	log.Infof("All images have been imported!")
+	return nil
+}
+
+func contains(items []string, item string) bool {
+	for _, currentItem := range items {
+		if currentItem == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+)
+
+// junitTestSuites is the root of a JUnit XML report, which is written with the junit flag
+// so that CI systems can show the result of each image as a test case.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is the result of an image. A test case passes when it has
+// neither a failure nor a skipped message.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func newJUnitTestSuite(name string, duration time.Duration, testCases []junitTestCase) junitTestSuite {
+	suite := junitTestSuite{
+		Name:      name,
+		Tests:     len(testCases),
+		Time:      getJUnitTime(duration.Seconds()),
+		TestCases: testCases,
+	}
+
+	for _, testCase := range testCases {
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+		if testCase.Skipped != nil {
+			suite.Skipped++
+		}
+	}
+
+	return suite
+}
+
+// getSyncTestCases returns a test case for each image of the report. Copied images pass,
+// skipped images are skipped with the reason, and failed images fail with the error.
+func getSyncTestCases(report *syncReport) []junitTestCase {
+	testCases := []junitTestCase{}
+	for _, image := range report.Images {
+		testCase := junitTestCase{
+			Name:      image.Source,
+			ClassName: report.Command,
+			Time:      getJUnitTime(image.Duration),
+		}
+
+		switch image.Action {
+		case actionSkipped:
+			testCase.Skipped = &junitMessage{Message: image.Reason}
+		case actionFailed:
+			testCase.Failure = &junitMessage{Message: image.Error, Text: image.Error}
+		}
+
+		testCases = append(testCases, testCase)
+	}
+
+	return testCases
+}
+
+// getVerifyTestCases returns a test case for each verified image, which fails
+// with the details of the result when the image is not ok.
+func getVerifyTestCases(results []verifyResult) []junitTestCase {
+	testCases := []junitTestCase{}
+	for _, result := range results {
+		testCase := junitTestCase{
+			Name:      result.Source,
+			ClassName: "verify",
+			Time:      getJUnitTime(0),
+		}
+
+		if result.Status != verifyOK {
+			testCase.Failure = &junitMessage{Message: result.Details, Text: result.Status}
+		}
+
+		testCases = append(testCases, testCase)
+	}
+
+	return testCases
+}
+
+// getCheckTestCases returns a test case for each checked image. An image fails when it could
+// not be checked, or when it has a newer version at or above the fail on level when one is set.
+// Images whose versions were not checked, such as images with an invalid version, are skipped.
+func getCheckTestCases(results []checkResult, failOn string) []junitTestCase {
+	testCases := []junitTestCase{}
+	for _, result := range results {
+		testCase := junitTestCase{
+			Name:      result.Image,
+			ClassName: "check",
+			Time:      getJUnitTime(0),
+		}
+
+		if result.Error != "" {
+			testCase.Failure = &junitMessage{Message: result.Error}
+		} else if result.Skipped != "" {
+			testCase.Skipped = &junitMessage{Message: result.Skipped}
+		} else if failOn != "" && exceedsFailOn(result, failOn) {
+			testCase.Failure = &junitMessage{
+				Message: fmt.Sprintf("newer %s version available (fail on %s)", getUpdateLevel(result), failOn),
+				Text:    fmt.Sprintf("%v", result.NewerVersions),
+			}
+		}
+
+		testCases = append(testCases, testCase)
+	}
+
+	return testCases
+}
+
+// writeJUnit writes the test suite as JUnit XML to the given path, when one is set.
+func writeJUnit(suite junitTestSuite, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	contents, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal junit: %w", err)
+	}
+
+	contents = append([]byte(xml.Header), contents...)
+	if err := os.WriteFile(path, contents, os.ModePerm); err != nil {
+		return fmt.Errorf("write junit: %w", err)
+	}
+
+	return nil
+}
+
+func getJUnitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
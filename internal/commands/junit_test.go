@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	report := newSyncReport("copy")
+	report.Images = []imageReport{
+		{Source: "busybox:1.0.0", Action: actionCopied},
+		{Source: "busybox:1.1.0", Action: actionSkipped, Reason: "exists at target"},
+		{Source: "alpine:3.0.0", Action: actionFailed, Reason: "missing at target", Error: "unauthorized"},
+	}
+
+	path := filepath.Join(t.TempDir(), "results.xml")
+	if err := writeJUnit(newJUnitTestSuite("copy", time.Second, getSyncTestCases(report)), path); err != nil {
+		t.Fatal("write junit:", err)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("read junit:", err)
+	}
+
+	var actual junitTestSuites
+	if err := xml.Unmarshal(contents, &actual); err != nil {
+		t.Fatal("unmarshal junit:", err)
+	}
+
+	if len(actual.Suites) != 1 {
+		t.Fatalf("expected 1 test suite, actual %d", len(actual.Suites))
+	}
+
+	suite := actual.Suites[0]
+	if suite.Tests != 3 || suite.Failures != 1 || suite.Skipped != 1 {
+		t.Errorf("expected 3 tests with 1 failure and 1 skipped, actual %d tests with %d failures and %d skipped", suite.Tests, suite.Failures, suite.Skipped)
+	}
+
+	if suite.TestCases[0].Failure != nil || suite.TestCases[0].Skipped != nil {
+		t.Errorf("expected %s to pass, actual %v", suite.TestCases[0].Name, suite.TestCases[0])
+	}
+
+	if suite.TestCases[1].Skipped == nil || suite.TestCases[1].Skipped.Message != "exists at target" {
+		t.Errorf("expected %s to be skipped, actual %v", suite.TestCases[1].Name, suite.TestCases[1])
+	}
+
+	if suite.TestCases[2].Failure == nil || suite.TestCases[2].Failure.Message != "unauthorized" {
+		t.Errorf("expected %s to fail, actual %v", suite.TestCases[2].Name, suite.TestCases[2])
+	}
+}
+
+func TestGetVerifyTestCases(t *testing.T) {
+	results := []verifyResult{
+		{Source: "busybox:1.0.0", Status: verifyOK},
+		{Source: "busybox:1.1.0", Status: verifyDrifted, Details: "linux/amd64"},
+		{Source: "alpine:3.0.0", Status: verifyError, Details: "get source digests: context deadline exceeded"},
+	}
+
+	testCases := getVerifyTestCases(results)
+	if len(testCases) != 3 {
+		t.Fatalf("expected 3 test cases, actual %d", len(testCases))
+	}
+
+	if testCases[0].Failure != nil {
+		t.Errorf("expected %s to pass, actual %v", testCases[0].Name, testCases[0].Failure)
+	}
+
+	if testCases[1].Failure == nil || testCases[1].Failure.Text != verifyDrifted {
+		t.Errorf("expected %s to fail as drifted, actual %v", testCases[1].Name, testCases[1].Failure)
+	}
+
+	if testCases[2].Failure == nil || testCases[2].Failure.Text != verifyError {
+		t.Errorf("expected %s to fail with an error, actual %v", testCases[2].Name, testCases[2].Failure)
+	}
+}
+
+func TestGetCheckTestCases(t *testing.T) {
+	results := []checkResult{
+		{Image: "busybox:1.0.0", NewerVersions: []string{"1.0.1"}, Newest: newestVersions{Patch: "1.0.1", Minor: "1.0.1", Major: "1.0.1"}},
+		{Image: "busybox:1.1.0", NewerVersions: []string{"2.0.0"}, Newest: newestVersions{Major: "2.0.0"}},
+		{Image: "alpine:3.0.0", Error: "get tags: unauthorized"},
+		{Image: "nginx:latest", Skipped: "invalid version"},
+	}
+
+	testCases := getCheckTestCases(results, "major")
+
+	if testCases[3].Skipped == nil || testCases[3].Skipped.Message != "invalid version" {
+		t.Errorf("expected %s to be skipped, actual %v", testCases[3].Name, testCases[3].Skipped)
+	}
+
+	expectedFailures := []bool{false, true, true}
+	for i, expected := range expectedFailures {
+		if actual := testCases[i].Failure != nil; actual != expected {
+			t.Errorf("expected failure of %s to be %v, actual %v", testCases[i].Name, expected, actual)
+		}
+	}
+}
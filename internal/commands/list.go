@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
 )
 
 func newListCommand() *cobra.Command {
@@ -18,8 +20,24 @@ func newListCommand() *cobra.Command {
 		Args:      cobra.ExactValidArgs(1),
 		ValidArgs: []string{"source", "target"},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if err := viper.BindPFlag("output", cmd.Flags().Lookup("output")); err != nil {
-				return fmt.Errorf("bind output flag: %w", err)
+			flags := []string{"output", "type", "format"}
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			if !contains([]string{"all", "image", "artifact"}, viper.GetString("type")) {
+				return fmt.Errorf("unknown type %s (must be all, image or artifact)", viper.GetString("type"))
+			}
+
+			if !contains([]string{"list", "kustomize"}, viper.GetString("format")) {
+				return fmt.Errorf("unknown format %s (must be list or kustomize)", viper.GetString("format"))
+			}
+
+			// The kustomize format maps each source to its target, so it only lists the targets.
+			if viper.GetString("format") == "kustomize" && !strings.EqualFold(args[0], "target") {
+				return errors.New("kustomize format can only be used with the target origin")
 			}
 
 			return nil
@@ -36,6 +54,8 @@ func newListCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringP("output", "o", "", "Output the images in the manifest to a file")
+	cmd.Flags().String("type", "all", "Type of the sources to list (all, image or artifact)")
+	cmd.Flags().String("format", "list", "Format of the images (list, or kustomize to map each source to its target in a kustomization images block)")
 
 	return &cmd
 }
@@ -48,12 +68,28 @@ func runListCommand(origin string) error {
 		return fmt.Errorf("get manifest: %w", err)
 	}
 
-	var images []string
+	var sources []manifest.Source
 	for _, source := range imageManifest.Sources {
-		if strings.EqualFold(origin, "target") {
-			images = append(images, source.TargetImage())
-		} else {
-			images = append(images, source.Image())
+		if isSourceOfType(source, viper.GetString("type")) {
+			sources = append(sources, source)
+		}
+	}
+
+	var images []string
+	if viper.GetString("format") == "kustomize" {
+		kustomization, err := getKustomizeImages(sources)
+		if err != nil {
+			return fmt.Errorf("get kustomize images: %w", err)
+		}
+
+		images = append(images, kustomization)
+	} else {
+		for _, source := range sources {
+			if strings.EqualFold(origin, "target") {
+				images = append(images, source.TargetImage())
+			} else {
+				images = append(images, source.Image())
+			}
 		}
 	}
 
@@ -84,3 +120,82 @@ func runListCommand(origin string) error {
 
 	return nil
 }
+
+// isSourceOfType returns true if the source is of the given type (all, image or artifact).
+func isSourceOfType(source manifest.Source, sourceType string) bool {
+	switch sourceType {
+	case "image":
+		return !source.IsArtifact()
+	case "artifact":
+		return source.IsArtifact()
+	default:
+		return true
+	}
+}
+
+// kustomizeImage is an image transformer in the images block of a kustomization.yaml.
+type kustomizeImage struct {
+	Name    string `yaml:"name"`
+	NewName string `yaml:"newName"`
+	NewTag  string `yaml:"newTag,omitempty"`
+}
+
+// getKustomizeImages returns a kustomization.yaml images block that
+// replaces the image of each source with its target image.
+func getKustomizeImages(sources []manifest.Source) (string, error) {
+	var kustomization struct {
+		Images []kustomizeImage `yaml:"images"`
+	}
+
+	// Kustomize matches the images by name, so each name can only have one entry. The tags
+	// are the same at the target, so only the name is replaced, unless the only source of
+	// the name is a digest. Digests are copied to a tag of the target, as the copy can
+	// change the digest, so the image is replaced with that tag.
+	sourcesByName := make(map[string][]manifest.Source)
+	var names []string
+	for _, source := range sources {
+		name, _ := splitImageTag(source.Image())
+		if _, exists := sourcesByName[name]; !exists {
+			names = append(names, name)
+		}
+
+		sourcesByName[name] = append(sourcesByName[name], source)
+	}
+
+	for _, name := range names {
+		image := kustomizeImage{Name: name}
+		for _, source := range sourcesByName[name] {
+			newName, _ := splitImageTag(source.TargetImage())
+			if image.NewName != "" && image.NewName != newName {
+				return "", fmt.Errorf("image %s is mirrored to both %s and %s", name, image.NewName, newName)
+			}
+
+			image.NewName = newName
+		}
+
+		if onlySource := sourcesByName[name][0]; len(sourcesByName[name]) == 1 && onlySource.Tag == "" {
+			_, image.NewTag = splitImageTag(onlySource.TargetImage())
+		}
+
+		kustomization.Images = append(kustomization.Images, image)
+	}
+
+	contents, err := yaml.Marshal(&kustomization)
+	if err != nil {
+		return "", fmt.Errorf("marshal kustomization: %w", err)
+	}
+
+	return strings.TrimSuffix(string(contents), "\n"), nil
+}
+
+// splitImageTag splits the image into its name and its tag. Any digest of the image is removed.
+func splitImageTag(image string) (string, string) {
+	image = strings.Split(image, "@")[0]
+
+	tagIndex := strings.LastIndex(image, ":")
+	if tagIndex == -1 || tagIndex < strings.LastIndex(image, "/") {
+		return image, ""
+	}
+
+	return image[:tagIndex], image[tagIndex+1:]
+}
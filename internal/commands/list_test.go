@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+)
+
+func TestIsSourceOfType(t *testing.T) {
+	image := manifest.Source{Repository: "busybox", Tag: "1.0.0"}
+	artifact := manifest.Source{Repository: "charts/nginx", Tag: "1.0.0", ArtifactType: "application/vnd.cncf.helm.config.v1+json"}
+
+	testCases := []struct {
+		source     manifest.Source
+		sourceType string
+		expected   bool
+	}{
+		{image, "all", true},
+		{image, "image", true},
+		{image, "artifact", false},
+		{artifact, "all", true},
+		{artifact, "image", false},
+		{artifact, "artifact", true},
+	}
+
+	for _, testCase := range testCases {
+		actual := isSourceOfType(testCase.source, testCase.sourceType)
+		if actual != testCase.expected {
+			t.Errorf("expected %s of type %s to be %v, actual %v", testCase.source.Image(), testCase.sourceType, testCase.expected, actual)
+		}
+	}
+}
+
+func TestGetKustomizeImages(t *testing.T) {
+	sources := []manifest.Source{
+		{Repository: "busybox", Tag: "1.0.0", Target: manifest.Target{Host: "mycr.com", Repository: "mirror"}},
+		{Repository: "busybox", Tag: "1.1.0", Target: manifest.Target{Host: "mycr.com", Repository: "mirror"}},
+		{Repository: "app/app", Host: "localhost:5000", Digest: "sha256:abc", Target: manifest.Target{Host: "mycr.com"}},
+	}
+
+	actual, err := getKustomizeImages(sources)
+	if err != nil {
+		t.Fatal("get kustomize images:", err)
+	}
+
+	expected := `images:
+- name: busybox
+  newName: mycr.com/mirror/busybox
+- name: localhost:5000/app/app
+  newName: mycr.com/app/app
+  newTag: abc`
+
+	if actual != expected {
+		t.Errorf("expected kustomize images %s, actual %s", expected, actual)
+	}
+
+	sources = append(sources, manifest.Source{Repository: "busybox", Tag: "1.2.0", Target: manifest.Target{Host: "othercr.com"}})
+	if _, err := getKustomizeImages(sources); err == nil {
+		t.Error("expected an error when an image is mirrored to more than one name")
+	}
+}
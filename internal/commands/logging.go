@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// configureLogging configures the format and level of the logs from the logging flags.
+// Quiet only logs errors, unless the log level is already more restrictive.
+func configureLogging() error {
+	switch viper.GetString("log-format") {
+	case "text":
+		log.SetFormatter(&log.TextFormatter{})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %s (must be text or json)", viper.GetString("log-format"))
+	}
+
+	level, err := log.ParseLevel(viper.GetString("log-level"))
+	if err != nil {
+		return fmt.Errorf("parse log level: %w", err)
+	}
+
+	if viper.GetBool("quiet") && level > log.ErrorLevel {
+		level = log.ErrorLevel
+	}
+
+	log.SetLevel(level)
+
+	return nil
+}
+
+// sourceLogger returns a logger with the image of the source, its target and the phase
+// of the command as fields, so that the logs of an image can be found by its fields.
+func sourceLogger(source manifest.Source, phase string) *log.Entry {
+	return log.WithFields(log.Fields{
+		"image":  source.Image(),
+		"target": source.TargetImage(),
+		"phase":  phase,
+	})
+}
+
+// imageLogger returns a logger with the image and the phase of the command as fields.
+func imageLogger(image string, phase string) *log.Entry {
+	return log.WithFields(log.Fields{
+		"image": image,
+		"phase": phase,
+	})
+}
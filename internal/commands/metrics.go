@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/plexsystems/sinker/internal/docker"
+	"github.com/plexsystems/sinker/internal/manifest"
+	"github.com/plexsystems/sinker/internal/metrics"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// serveMetrics serves the metrics at /metrics of the metrics address. The returned
+// function shuts down the server, and nothing is served when no address is set.
+func serveMetrics() func() {
+	if viper.GetString("metrics-addr") == "" {
+		return func() {}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	server := http.Server{
+		Addr:              viper.GetString("metrics-addr"),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Error("Unable to serve metrics")
+		}
+	}()
+
+	log.WithField("addr", server.Addr).Info("Serving metrics")
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		server.Shutdown(ctx)
+	}
+}
+
+// writeMetricsTextfile writes the metrics to the metrics textfile, when one is set.
+func writeMetricsTextfile() error {
+	if viper.GetString("metrics-textfile") == "" {
+		return nil
+	}
+
+	if err := metrics.WriteTextfile(viper.GetString("metrics-textfile")); err != nil {
+		return fmt.Errorf("write metrics textfile: %w", err)
+	}
+
+	return nil
+}
+
+// getMetricLabels returns the source host and target labels of the metrics of the source.
+func getMetricLabels(source manifest.Source) []string {
+	target := strings.TrimSuffix(source.Target.Host+"/"+source.Target.Repository, "/")
+	return []string{getSourceHost(source), target}
+}
+
+// getPullMetricLabels returns the source host and target labels of the metrics of an image
+// pulled by pull, whose target is the Docker host.
+func getPullMetricLabels(image string) []string {
+	host := docker.RegistryPath(image).Host()
+	if host == "" {
+		host = "docker.io"
+	}
+
+	return []string{host, "docker"}
+}
@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetPullMetricLabels(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected []string
+	}{
+		{"busybox:1.0.0", []string{"docker.io", "docker"}},
+		{"quay.io/coreos/prometheus-operator:v0.40.0", []string{"quay.io", "docker"}},
+	}
+
+	for _, testCase := range testCases {
+		actual := getPullMetricLabels(testCase.image)
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("expected labels of %s to be %v, actual %v", testCase.image, testCase.expected, actual)
+		}
+	}
+}
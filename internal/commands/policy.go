@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	dockerv5 "github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/signature"
+	"github.com/spf13/viper"
+)
+
+// newPolicyContext returns the policy context used to decide whether the source
+// images may be copied.
+//
+// When no policy file is given, unsigned images are accepted. The verification
+// requirements of each source are added to the policy for its repository.
+func newPolicyContext(sources []manifest.Source) (*signature.PolicyContext, error) {
+	policy := &signature.Policy{
+		Default: []signature.PolicyRequirement{
+			signature.NewPRInsecureAcceptAnything(),
+		},
+	}
+
+	if viper.GetString("policy") != "" {
+		var err error
+		policy, err = signature.NewPolicyFromFile(viper.GetString("policy"))
+		if err != nil {
+			return nil, fmt.Errorf("new policy from file: %w", err)
+		}
+	}
+
+	for _, source := range sources {
+		requirements, err := getPolicyRequirements(source.Verify)
+		if err != nil {
+			return nil, fmt.Errorf("get policy requirements: %w", err)
+		}
+
+		if len(requirements) == 0 {
+			continue
+		}
+
+		named, err := reference.ParseNormalizedNamed(source.Image())
+		if err != nil {
+			return nil, fmt.Errorf("parse source image: %w", err)
+		}
+
+		if policy.Transports == nil {
+			policy.Transports = make(map[string]signature.PolicyTransportScopes)
+		}
+
+		transport := dockerv5.Transport.Name()
+		if policy.Transports[transport] == nil {
+			policy.Transports[transport] = make(signature.PolicyTransportScopes)
+		}
+
+		policy.Transports[transport][named.Name()] = requirements
+	}
+
+	policyContext, err := signature.NewPolicyContext(policy)
+	if err != nil {
+		return nil, fmt.Errorf("new policy context: %w", err)
+	}
+
+	return policyContext, nil
+}
+
+func getPolicyRequirements(verification manifest.Verification) (signature.PolicyRequirements, error) {
+	var requirements signature.PolicyRequirements
+
+	// Signatures created by cosign identify the repository of the image rather
+	// than the tag, so any signature for the repository is accepted.
+	if verification.SigstorePublicKey != "" {
+		requirement, err := signature.NewPRSigstoreSignedKeyPath(verification.SigstorePublicKey, signature.NewPRMMatchRepository())
+		if err != nil {
+			return nil, fmt.Errorf("new sigstore requirement: %w", err)
+		}
+
+		requirements = append(requirements, requirement)
+	}
+
+	if verification.GPGKey != "" {
+		requirement, err := signature.NewPRSignedByKeyPath(signature.SBKeyTypeGPGKeys, verification.GPGKey, signature.NewPRMMatchRepoDigestOrExact())
+		if err != nil {
+			return nil, fmt.Errorf("new gpg requirement: %w", err)
+		}
+
+		requirements = append(requirements, requirement)
+	}
+
+	return requirements, nil
+}
+
+// getRegistriesDir returns the registries.d directory that configures where
+// signatures are stored, and a function that cleans up the directory.
+//
+// Sigstore signatures are only used when enabled in registries.d, so when no
+// directory is given and sigstore is used, a temporary directory enabling
+// sigstore attachments is created.
+func getRegistriesDir(useSigstore bool) (string, func(), error) {
+	if viper.GetString("registries-dir") != "" || !useSigstore {
+		return viper.GetString("registries-dir"), func() {}, nil
+	}
+
+	registriesDir, err := os.MkdirTemp("", "sinker-registries.d")
+	if err != nil {
+		return "", nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	cleanup := func() {
+		os.RemoveAll(registriesDir)
+	}
+
+	config := "default-docker:\n  use-sigstore-attachments: true\n"
+	if err := os.WriteFile(filepath.Join(registriesDir, "sinker.yaml"), []byte(config), os.ModePerm); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("write config: %w", err)
+	}
+
+	return registriesDir, cleanup, nil
+}
+
+func requiresSigstoreVerification(sources []manifest.Source) bool {
+	for _, source := range sources {
+		if source.Verify.SigstorePublicKey != "" {
+			return true
+		}
+	}
+
+	return false
+}
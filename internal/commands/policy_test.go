@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+)
+
+func TestGetPolicyRequirements(t *testing.T) {
+	testCases := []struct {
+		verification manifest.Verification
+		expected     int
+	}{
+		{
+			manifest.Verification{},
+			0,
+		},
+		{
+			manifest.Verification{SigstorePublicKey: "cosign.pub"},
+			1,
+		},
+		{
+			manifest.Verification{SigstorePublicKey: "cosign.pub", GPGKey: "key.gpg"},
+			2,
+		},
+	}
+
+	for _, testCase := range testCases {
+		requirements, err := getPolicyRequirements(testCase.verification)
+		if err != nil {
+			t.Fatal("get policy requirements:", err)
+		}
+
+		if len(requirements) != testCase.expected {
+			t.Errorf("expected %d requirements, actual %d", testCase.expected, len(requirements))
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/plexsystems/sinker/internal/docker"
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/hashicorp/go-version"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// keepRules are the rules that decide which of the tags that are no
+// longer in the manifest are kept in the target repository.
+type keepRules struct {
+	newest int
+	tags   *regexp.Regexp
+}
+
+// imageToPrune is an image, referenced by digest, that will be deleted
+// from the target along with the tags that reference it.
+type imageToPrune struct {
+	image string
+	tags  []string
+}
+
+func (i imageToPrune) String() string {
+	return fmt.Sprintf("%s (%s)", i.image, strings.Join(i.tags, ", "))
+}
+
+func newPruneCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "prune",
+		Short: "Delete the images in the target repositories that are no longer in the manifest",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			flags := []string{"dryrun", "force", "keep-newest", "keep-tags"}
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runPruneCommand(); err != nil {
+				return fmt.Errorf("prune: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("dryrun", false, "Print a list of images that would be deleted from the target")
+	cmd.Flags().Bool("force", false, "Delete the images without asking for confirmation")
+	cmd.Flags().Int("keep-newest", 0, "Number of the newest versions to keep in each repository, even if they are no longer in the manifest")
+	cmd.Flags().String("keep-tags", "", "Regular expression of the tags to keep, even if they are no longer in the manifest")
+
+	return &cmd
+}
+
+func runPruneCommand() error {
+	ctx, cancel := newTimeoutContext(context.Background())
+	defer cancel()
+
+	client, err := newDockerClient()
+	if err != nil {
+		return fmt.Errorf("new client: %w", err)
+	}
+
+	imageManifest, err := manifest.Get(viper.GetString("manifest"))
+	if err != nil {
+		return fmt.Errorf("get manifest: %w", err)
+	}
+
+	rules := keepRules{
+		newest: viper.GetInt("keep-newest"),
+	}
+	if viper.GetString("keep-tags") != "" {
+		rules.tags, err = regexp.Compile(viper.GetString("keep-tags"))
+		if err != nil {
+			return fmt.Errorf("compile keep tags: %w", err)
+		}
+	}
+
+	repositories, sourceTags, sourceDigests, err := getSourceTagsByRepository(imageManifest.Sources)
+	if err != nil {
+		return fmt.Errorf("get source tags: %w", err)
+	}
+
+	log.Infof("Finding images that need to be pruned ...")
+
+	var imagesToDelete []imageToPrune
+	for _, repository := range repositories {
+		images, err := getImagesToPrune(ctx, client, repository, sourceTags[repository], sourceDigests[repository], rules)
+		if err != nil {
+			return fmt.Errorf("get images to prune in %s: %w", repository, err)
+		}
+
+		imagesToDelete = append(imagesToDelete, images...)
+	}
+
+	if len(imagesToDelete) == 0 {
+		log.Infof("No images need to be pruned!")
+		return nil
+	}
+
+	if viper.GetBool("dryrun") {
+		for _, image := range imagesToDelete {
+			imageLogger(image.image, "prune").WithField("tags", image.tags).Info("Image would be deleted")
+		}
+
+		return nil
+	}
+
+	if !viper.GetBool("force") {
+		for _, image := range imagesToDelete {
+			fmt.Println(image)
+		}
+
+		confirmed, err := confirm(fmt.Sprintf("Delete %d images?", len(imagesToDelete)))
+		if err != nil {
+			return fmt.Errorf("confirm: %w", err)
+		}
+
+		if !confirmed {
+			log.Infof("No images were deleted")
+			return nil
+		}
+	}
+
+	for _, image := range imagesToDelete {
+		imageLogger(image.image, "prune").WithField("tags", image.tags).Info("Deleting image")
+
+		if err := client.DeleteImage(ctx, image.image); err != nil {
+			return fmt.Errorf("delete image: %w", err)
+		}
+	}
+
+	log.Infof("All images have been pruned!")
+	return nil
+}
+
+// getSourceTagsByRepository groups the tags and digests of the sources by the target repository
+// they are mapped to, as these are the repositories managed by sinker. The tag of a source is the
+// tag of its target image, which for a source pinned to a digest is the hex of the digest.
+func getSourceTagsByRepository(sources []manifest.Source) ([]string, map[string][]string, map[string][]string, error) {
+	var repositories []string
+	sourceTags := make(map[string][]string)
+	sourceDigests := make(map[string][]string)
+	for _, source := range sources {
+		reference, err := name.ParseReference(source.TargetImage(), name.WeakValidation)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("parse target ref: %w", err)
+		}
+
+		repository := reference.Context().String()
+		if !contains(repositories, repository) {
+			repositories = append(repositories, repository)
+		}
+
+		sourceTags[repository] = append(sourceTags[repository], reference.Identifier())
+		if source.Digest != "" {
+			sourceDigests[repository] = append(sourceDigests[repository], source.Digest)
+		}
+	}
+
+	return repositories, sourceTags, sourceDigests, nil
+}
+
+// getImagesToPrune returns the images, referenced by digest, that can be deleted from
+// the repository. The tags of an image are only deleted when every tag that references
+// the image can be deleted, as deleting an image by digest also deletes all of its tags.
+func getImagesToPrune(ctx context.Context, client docker.Client, repository string, sourceTags []string, sourceDigests []string, rules keepRules) ([]imageToPrune, error) {
+	reference, err := name.NewRepository(repository, name.WeakValidation)
+	if err != nil {
+		return nil, fmt.Errorf("parse repository: %w", err)
+	}
+
+	tags, err := client.GetTagsForRepository(ctx, reference.RegistryStr(), reference.RepositoryStr())
+	if docker.IsNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get tags: %w", err)
+	}
+
+	tagsToPrune := getTagsToPrune(tags, sourceTags, rules)
+	if len(tagsToPrune) == 0 {
+		return nil, nil
+	}
+
+	keptDigests := append([]string{}, sourceDigests...)
+	for _, tag := range tags {
+		if contains(tagsToPrune, tag) || docker.IsAttachedTag(tag) {
+			continue
+		}
+
+		digest, err := client.GetDigest(ctx, reference.Tag(tag).String())
+		if err != nil {
+			return nil, fmt.Errorf("get digest of %s: %w", tag, err)
+		}
+
+		keptDigests = append(keptDigests, digest)
+	}
+
+	var images []imageToPrune
+	for _, tag := range tagsToPrune {
+		digest, err := client.GetDigest(ctx, reference.Tag(tag).String())
+		if err != nil {
+			return nil, fmt.Errorf("get digest of %s: %w", tag, err)
+		}
+
+		if contains(keptDigests, digest) {
+			imageLogger(repository+":"+tag, "prune").Info("Image shares its digest with an image that is kept. Skipping ...")
+			continue
+		}
+
+		image := reference.Digest(digest).String()
+		found := false
+		for i := range images {
+			if images[i].image == image {
+				images[i].tags = append(images[i].tags, tag)
+				found = true
+			}
+		}
+
+		if !found {
+			images = append(images, imageToPrune{image: image, tags: []string{tag}})
+		}
+	}
+
+	// The signatures, attestations and SBOMs attached to an image are deleted along with
+	// the image, as they would otherwise be left behind without an image to attach to.
+	var attachedImages []imageToPrune
+	for _, image := range images {
+		imageDigest := strings.TrimPrefix(image.image, reference.String()+"@")
+		for _, tag := range getAttachedTags(tags, imageDigest) {
+			digest, err := client.GetDigest(ctx, reference.Tag(tag).String())
+			if err != nil {
+				return nil, fmt.Errorf("get digest of %s: %w", tag, err)
+			}
+
+			attachedImages = append(attachedImages, imageToPrune{image: reference.Digest(digest).String(), tags: []string{tag}})
+		}
+	}
+
+	return append(images, attachedImages...), nil
+}
+
+// getAttachedTags returns the tags used by cosign to attach artifacts to the image with the given digest.
+func getAttachedTags(tags []string, digest string) []string {
+	prefix := strings.Replace(digest, "sha256:", "sha256-", 1) + "."
+
+	var attachedTags []string
+	for _, tag := range tags {
+		if docker.IsAttachedTag(tag) && strings.HasPrefix(tag, prefix) {
+			attachedTags = append(attachedTags, tag)
+		}
+	}
+
+	return attachedTags
+}
+
+// getTagsToPrune returns the tags that are not used by any source and are not kept
+// by the keep rules. Tags used by cosign to attach artifacts to images are pruned along
+// with the image they are attached to, rather than by their tag.
+func getTagsToPrune(tags []string, sourceTags []string, rules keepRules) []string {
+	var candidates []string
+	for _, tag := range tags {
+		if contains(sourceTags, tag) || docker.IsAttachedTag(tag) {
+			continue
+		}
+
+		if rules.tags != nil && rules.tags.MatchString(tag) {
+			continue
+		}
+
+		candidates = append(candidates, tag)
+	}
+
+	newestTags := getNewestTags(candidates, rules.newest)
+
+	var tagsToPrune []string
+	for _, tag := range candidates {
+		if !contains(newestTags, tag) {
+			tagsToPrune = append(tagsToPrune, tag)
+		}
+	}
+
+	return tagsToPrune
+}
+
+// getNewestTags returns the newest tags by version. Tags that are not
+// a valid version are never considered to be one of the newest tags.
+func getNewestTags(tags []string, count int) []string {
+	var versions []*version.Version
+	for _, tag := range tags {
+		tagVersion, err := version.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, tagVersion)
+	}
+
+	sort.Sort(sort.Reverse(version.Collection(versions)))
+	if len(versions) > count {
+		versions = versions[:count]
+	}
+
+	var newestTags []string
+	for _, tagVersion := range versions {
+		newestTags = append(newestTags, tagVersion.Original())
+	}
+
+	return newestTags
+}
+
+// confirm asks the user to confirm the given message on standard input.
+func confirm(message string) (bool, error) {
+	fmt.Printf("%s [y/N]: ", message)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("read answer: %w", err)
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+)
+
+func TestGetTagsToPrune(t *testing.T) {
+	tags := []string{
+		"1.0.0",
+		"1.1.0",
+		"1.2.0",
+		"2.0.0",
+		"latest",
+		"stable",
+		"sha256-9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.sig",
+	}
+	sourceTags := []string{"2.0.0"}
+
+	testCases := []struct {
+		name     string
+		rules    keepRules
+		expected []string
+	}{
+		{
+			name:     "no rules",
+			expected: []string{"1.0.0", "1.1.0", "1.2.0", "latest", "stable"},
+		},
+		{
+			name:     "keep newest",
+			rules:    keepRules{newest: 2},
+			expected: []string{"1.0.0", "latest", "stable"},
+		},
+		{
+			name:     "keep tags",
+			rules:    keepRules{tags: regexp.MustCompile("^(latest|stable)$")},
+			expected: []string{"1.0.0", "1.1.0", "1.2.0"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		actual := getTagsToPrune(tags, sourceTags, testCase.rules)
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("%s: expected tags to prune %v, actual %v", testCase.name, testCase.expected, actual)
+		}
+	}
+}
+
+func TestGetNewestTags(t *testing.T) {
+	tags := []string{"v1.10.0", "v1.2.0", "latest", "v1.9.0"}
+
+	actual := getNewestTags(tags, 2)
+	expected := []string{"v1.10.0", "v1.9.0"}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected newest tags %v, actual %v", expected, actual)
+	}
+}
+
+func TestGetSourceTagsByRepository(t *testing.T) {
+	target := manifest.Target{Host: "mycr.com", Repository: "mirror"}
+	sources := []manifest.Source{
+		{Host: "docker.io", Repository: "library/busybox", Tag: "1.0.0", Target: target},
+		{Host: "docker.io", Repository: "library/busybox", Digest: "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", Target: target},
+	}
+
+	repositories, sourceTags, sourceDigests, err := getSourceTagsByRepository(sources)
+	if err != nil {
+		t.Fatal("get source tags:", err)
+	}
+
+	repository := "mycr.com/mirror/library/busybox"
+	if !reflect.DeepEqual(repositories, []string{repository}) {
+		t.Errorf("expected repositories %v, actual %v", []string{repository}, repositories)
+	}
+
+	expectedTags := []string{"1.0.0", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}
+	if !reflect.DeepEqual(sourceTags[repository], expectedTags) {
+		t.Errorf("expected source tags %v, actual %v", expectedTags, sourceTags[repository])
+	}
+
+	expectedDigests := []string{"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}
+	if !reflect.DeepEqual(sourceDigests[repository], expectedDigests) {
+		t.Errorf("expected source digests %v, actual %v", expectedDigests, sourceDigests[repository])
+	}
+
+	tags := []string{"1.0.0", "1.1.0", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}
+	actual := getTagsToPrune(tags, sourceTags[repository], keepRules{})
+	if !reflect.DeepEqual(actual, []string{"1.1.0"}) {
+		t.Errorf("expected tags to prune %v, actual %v", []string{"1.1.0"}, actual)
+	}
+}
+
+func TestGetAttachedTags(t *testing.T) {
+	tags := []string{
+		"1.0.0",
+		"sha256-9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.sig",
+		"sha256-9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.att",
+		"sha256-60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752.sig",
+	}
+
+	actual := getAttachedTags(tags, "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+	expected := []string{
+		"sha256-9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.sig",
+		"sha256-9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.att",
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected attached tags %v, actual %v", expected, actual)
+	}
+}
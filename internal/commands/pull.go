@@ -8,6 +8,7 @@ import (
 
 	"github.com/plexsystems/sinker/internal/docker"
 	"github.com/plexsystems/sinker/internal/manifest"
+	"github.com/plexsystems/sinker/internal/metrics"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -21,8 +22,16 @@ func newPullCommand() *cobra.Command {
 		Args:      cobra.OnlyValidArgs,
 		ValidArgs: []string{"source", "target"},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if err := viper.BindPFlag("images", cmd.Flags().Lookup("images")); err != nil {
-				return fmt.Errorf("bind images flag: %w", err)
+			flags := []string{"images", "metrics-textfile", "report"}
+			flags = append(flags, failureFlags...)
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			if err := validateFailureFlags(); err != nil {
+				return err
 			}
 
 			return nil
@@ -33,7 +42,21 @@ func newPullCommand() *cobra.Command {
 				origin = args[0]
 			}
 
-			if err := runPullCommand(origin); err != nil {
+			// The metrics are also written when the pull fails, so that failures can be alerted on.
+			defer func() {
+				if err := writeMetricsTextfile(); err != nil {
+					log.WithError(err).Error("Unable to write metrics")
+				}
+			}()
+
+			report := newSyncReport("pull")
+			defer func() {
+				if err := writeReport(report, viper.GetString("report")); err != nil {
+					log.WithError(err).Error("Unable to write report")
+				}
+			}()
+
+			if err := runPullCommand(origin, report); err != nil {
 				return fmt.Errorf("pull: %w", err)
 			}
 
@@ -42,42 +65,69 @@ func newPullCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringSliceP("images", "i", []string{}, "List of images to pull (e.g. host.com/repo:v1.0.0)")
+	cmd.Flags().String("metrics-textfile", "", "Path to write the Prometheus metrics of the pull to for the node exporter textfile collector")
+	cmd.Flags().String("report", "", "Path to write a JSON report of the action taken for each image to")
+	addFailureFlags(&cmd)
 
 	return &cmd
 }
 
-func runPullCommand(origin string) error {
+// pullOptions are the options to pull an image with.
+type pullOptions struct {
+	auth    string
+	timeout time.Duration
+}
+
+func runPullCommand(origin string, report *syncReport) error {
 	manifestPath := viper.GetString("manifest")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	ctx, cancel := newTimeoutContext(context.Background())
 	defer cancel()
 
-	client, err := docker.New(log.Infof)
+	client, err := newDockerClient()
 	if err != nil {
 		return fmt.Errorf("new client: %w", err)
 	}
+	defer report.resolveDigests(client)
 
-	var images map[string]string
+	var images map[string]pullOptions
+	var artifacts []string
 	if len(viper.GetStringSlice("images")) > 0 {
 		images, err = getImagesFromCommandLine(viper.GetStringSlice("images"))
 	} else {
-		images, err = getImagesFromManifest(manifestPath, origin)
+		images, artifacts, err = getImagesFromManifest(manifestPath, origin)
 	}
 	if err != nil {
 		return fmt.Errorf("get images: %w", err)
 	}
 
-	log.Infof("Finding images that need to be pulled from %v registry ...", origin)
+	for _, artifact := range artifacts {
+		report.add(imageReport{Source: artifact}, actionSkipped, "artifacts cannot be pulled through the docker daemon", nil)
+	}
+
+	log.WithField("origin", origin).Info("Finding images that need to be pulled ...")
+
+	failures := newSyncFailures()
 
-	imagesToPull := make(map[string]string)
-	for image, auth := range images {
+	imagesToPull := make(map[string]pullOptions)
+	for image, options := range images {
+		metrics.ImagesChecked.WithLabelValues(getPullMetricLabels(image)...).Inc()
 		exists, err := client.ImageExistsOnHost(ctx, image)
 		if err != nil {
-			return fmt.Errorf("image host existence: %w", err)
+			metrics.ImagesFailed.WithLabelValues(getPullMetricLabels(image)...).Inc()
+			report.add(imageReport{Source: image}, actionFailed, "unable to check the docker host", err)
+			if err := failures.add(ctx, image, fmt.Errorf("image host existence: %w", err)); err != nil {
+				return err
+			}
+
+			continue
 		}
 
 		if !exists {
-			imagesToPull[image] = auth
+			imagesToPull[image] = options
+		} else {
+			metrics.ImagesSkipped.WithLabelValues(getPullMetricLabels(image)...).Inc()
+			report.add(imageReport{Source: image}, actionSkipped, "exists on docker host", nil)
 		}
 	}
 
@@ -86,31 +136,66 @@ func runPullCommand(origin string) error {
 	// performing the pull operation.
 	for image := range imagesToPull {
 		if _, err := client.ImageExistsAtRemote(ctx, image); err != nil {
-			return fmt.Errorf("validating remote image: %w", err)
+			metrics.ImagesFailed.WithLabelValues(getPullMetricLabels(image)...).Inc()
+			report.add(imageReport{Source: image}, actionFailed, "unable to access the remote image", err)
+			if err := failures.add(ctx, image, fmt.Errorf("validating remote image: %w", err)); err != nil {
+				return err
+			}
+
+			delete(imagesToPull, image)
 		}
 	}
 
-	for image, auth := range imagesToPull {
-		log.Infof("Pulling %s", image)
+	for image, options := range imagesToPull {
+		imageLogger(image, "pull").Info("Pulling image")
+
+		imageCtx, cancel := newImageContext(ctx, options.timeout)
+		start := time.Now()
+		err := client.PullAndWait(imageCtx, image, options.auth)
+		cancel()
+		duration := time.Since(start).Seconds()
+		if err != nil {
+			metrics.ImagesFailed.WithLabelValues(getPullMetricLabels(image)...).Inc()
+			report.add(imageReport{Source: image, Duration: duration}, actionFailed, "missing on docker host", err)
+			if err := failures.add(ctx, image, fmt.Errorf("pull image and wait: %w", err)); err != nil {
+				return err
+			}
 
-		if err := client.PullAndWait(ctx, image, auth); err != nil {
-			log.Errorf("pull image and wait: " + err.Error())
+			continue
 		}
+
+		metrics.ImagesCopied.WithLabelValues(getPullMetricLabels(image)...).Inc()
+		metrics.CopyDuration.WithLabelValues(getPullMetricLabels(image)...).Observe(duration)
+		report.add(imageReport{Source: image, Duration: duration}, actionCopied, "missing on docker host", nil)
+	}
+
+	if failures.failed() {
+		return failures.summary(len(images))
 	}
 
+	metrics.LastSuccess.SetToCurrentTime()
 	log.Infof("All images have been pulled!")
 
 	return nil
 }
 
-func getImagesFromManifest(path string, origin string) (map[string]string, error) {
+// getImagesFromManifest returns the images of the manifest from the given origin with the options
+// to pull them with, as well as the artifacts of the manifest, which cannot be pulled.
+func getImagesFromManifest(path string, origin string) (map[string]pullOptions, []string, error) {
 	imageManifest, err := manifest.Get(path)
 	if err != nil {
-		return nil, fmt.Errorf("get manifest: %w", err)
+		return nil, nil, fmt.Errorf("get manifest: %w", err)
 	}
 
-	images := make(map[string]string)
+	images := make(map[string]pullOptions)
+	var artifacts []string
 	for _, source := range imageManifest.Sources {
+		if source.IsArtifact() {
+			imageLogger(source.Image(), "pull").Info("Artifacts cannot be pulled through the Docker daemon. Skipping ...")
+			artifacts = append(artifacts, source.Image())
+			continue
+		}
+
 		var image string
 		var auth string
 
@@ -123,17 +208,17 @@ func getImagesFromManifest(path string, origin string) (map[string]string, error
 			auth, err = source.EncodedAuth()
 		}
 		if err != nil {
-			return nil, fmt.Errorf("get %s auth: %w", origin, err)
+			return nil, nil, fmt.Errorf("get %s auth: %w", origin, err)
 		}
 
-		images[image] = auth
+		images[image] = pullOptions{auth: auth, timeout: getImageTimeout(source)}
 	}
 
-	return images, nil
+	return images, artifacts, nil
 }
 
-func getImagesFromCommandLine(images []string) (map[string]string, error) {
-	imgs := make(map[string]string)
+func getImagesFromCommandLine(images []string) (map[string]pullOptions, error) {
+	imgs := make(map[string]pullOptions)
 	for _, image := range images {
 		registryPath := docker.RegistryPath(image)
 
@@ -142,7 +227,7 @@ func getImagesFromCommandLine(images []string) (map[string]string, error) {
 			return nil, fmt.Errorf("get auth: %w", err)
 		}
 
-		imgs[image] = auth
+		imgs[image] = pullOptions{auth: auth, timeout: viper.GetDuration("image-timeout")}
 	}
 
 	return imgs, nil
@@ -8,7 +8,11 @@ import (
 
 	"github.com/plexsystems/sinker/internal/docker"
 	"github.com/plexsystems/sinker/internal/manifest"
+	"github.com/plexsystems/sinker/internal/metrics"
 
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/types"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -19,7 +23,9 @@ func newPushCommand() *cobra.Command {
 		Use:   "push",
 		Short: "Push the images in the manifest to the target repository",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			flags := []string{"dryrun", "images", "target"}
+			flags := []string{"dryrun", "images", "target", "registries-dir", "metrics-textfile", "report", "junit"}
+			flags = append(flags, signingFlags...)
+			flags = append(flags, failureFlags...)
 			for _, flag := range flags {
 				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
 					return fmt.Errorf("bind flag: %w", err)
@@ -30,10 +36,33 @@ func newPushCommand() *cobra.Command {
 				return errors.New("target must be specified when using the images flag")
 			}
 
+			if err := validateFailureFlags(); err != nil {
+				return err
+			}
+
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := runPushCommand(); err != nil {
+			// The metrics are also written when the push fails, so that failures can be alerted on.
+			defer func() {
+				if err := writeMetricsTextfile(); err != nil {
+					log.WithError(err).Error("Unable to write metrics")
+				}
+			}()
+
+			report := newSyncReport("push")
+			defer func() {
+				if err := writeReport(report, viper.GetString("report")); err != nil {
+					log.WithError(err).Error("Unable to write report")
+				}
+
+				suite := newJUnitTestSuite("push", time.Since(report.Started), getSyncTestCases(report))
+				if err := writeJUnit(suite, viper.GetString("junit")); err != nil {
+					log.WithError(err).Error("Unable to write JUnit report")
+				}
+			}()
+
+			if err := runPushCommand(report); err != nil {
 				return fmt.Errorf("push: %w", err)
 			}
 
@@ -44,18 +73,25 @@ func newPushCommand() *cobra.Command {
 	cmd.Flags().Bool("dryrun", false, "Print a list of images that would be pushed to the target")
 	cmd.Flags().StringSliceP("images", "i", []string{}, "List of images to push to target")
 	cmd.Flags().StringP("target", "t", "", "Registry the images will be pushed to")
+	cmd.Flags().String("registries-dir", "", "Path to a registries.d directory configuring where signatures are stored")
+	cmd.Flags().String("metrics-textfile", "", "Path to write the Prometheus metrics of the push to for the node exporter textfile collector")
+	cmd.Flags().String("report", "", "Path to write a JSON report of the action taken for each image to")
+	cmd.Flags().String("junit", "", "Path to write a JUnit XML report with a test case for each image to")
+	addSigningFlags(&cmd)
+	addFailureFlags(&cmd)
 
 	return &cmd
 }
 
-func runPushCommand() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+func runPushCommand(report *syncReport) error {
+	ctx, cancel := newTimeoutContext(context.Background())
 	defer cancel()
 
-	client, err := docker.New(log.Infof)
+	client, err := newDockerClient()
 	if err != nil {
 		return fmt.Errorf("new client: %w", err)
 	}
+	defer report.resolveDigests(client)
 
 	var sources []manifest.Source
 	if len(viper.GetStringSlice("images")) > 0 {
@@ -71,70 +107,158 @@ func runPushCommand() error {
 
 	log.Infof("Finding images that need to be pushed ...")
 
+	failures := newSyncFailures()
+
 	var sourcesToPush []manifest.Source
 	for _, source := range sources {
+		image := imageReport{Source: source.Image(), Target: source.TargetImage()}
+
+		if source.IsArtifact() {
+			sourceLogger(source, "push").Info("Artifacts cannot be pushed through the Docker daemon. Skipping ...")
+			report.add(image, actionSkipped, "artifacts cannot be pushed through the docker daemon", nil)
+			continue
+		}
+
+		metrics.ImagesChecked.WithLabelValues(getMetricLabels(source)...).Inc()
 		exists, err := client.ImageExistsAtRemote(ctx, source.TargetImage())
 		if err != nil {
-			return fmt.Errorf("image exists at remote: %w", err)
+			metrics.ImagesFailed.WithLabelValues(getMetricLabels(source)...).Inc()
+			report.add(image, actionFailed, "unable to check the target", err)
+			if err := failures.add(ctx, source.Image(), fmt.Errorf("image exists at remote: %w", err)); err != nil {
+				return err
+			}
+
+			continue
 		}
 
 		if !exists {
 			sourcesToPush = append(sourcesToPush, source)
+		} else {
+			metrics.ImagesSkipped.WithLabelValues(getMetricLabels(source)...).Inc()
+			report.add(image, actionSkipped, "exists at target", nil)
 		}
 	}
 
 	if len(sourcesToPush) == 0 {
+		if failures.failed() {
+			return failures.summary(len(sources))
+		}
+
+		metrics.LastSuccess.SetToCurrentTime()
 		log.Infof("All images are up to date!")
 		return nil
 	}
 
 	if viper.GetBool("dryrun") {
 		for _, source := range sourcesToPush {
-			log.Infof("Image %s would be pushed as %s", source.Image(), source.TargetImage())
+			sourceLogger(source, "push").Info("Image would be pushed")
+			report.add(imageReport{Source: source.Image(), Target: source.TargetImage()}, actionSkipped, "dry run", nil)
 		}
-		return nil
+
+		return failures.summary(len(sources))
 	}
 
-	for _, source := range sourcesToPush {
-		sourceExists, err := client.ImageExistsOnHost(ctx, source.Image())
+	// Images pushed through the Docker daemon cannot be signed as they are pushed,
+	// so each image is signed at the target once it has been pushed.
+	var policyContext *signature.PolicyContext
+	var copyOptions copy.Options
+	if signingEnabled() {
+		policyContext, err = newPolicyContext(nil)
 		if err != nil {
-			return fmt.Errorf("image exists: %w", err)
+			return fmt.Errorf("new policy context: %w", err)
 		}
+		defer policyContext.Destroy()
 
-		if !sourceExists {
-			log.Infof("Pulling %s", source.Image())
-
-			sourceAuth, err := source.EncodedAuth()
-			if err != nil {
-				return fmt.Errorf("get source auth: %w", err)
-			}
-			if err := client.PullAndWait(ctx, source.Image(), sourceAuth); err != nil {
-				return fmt.Errorf("pull image and wait: %w", err)
-			}
+		registriesDir, cleanup, err := getRegistriesDir(viper.GetString("sign-by-sigstore-key") != "")
+		if err != nil {
+			return fmt.Errorf("get registries dir: %w", err)
 		}
+		defer cleanup()
+
+		copyOptions.SourceCtx = &types.SystemContext{RegistriesDirPath: registriesDir}
+		copyOptions.DestinationCtx = &types.SystemContext{RegistriesDirPath: registriesDir}
+	}
 
-		targetExists, err := client.ImageExistsOnHost(ctx, source.TargetImage())
+	for _, source := range sourcesToPush {
+		image := imageReport{Source: source.Image(), Target: source.TargetImage()}
+
+		start := time.Now()
+		imageCtx, cancel := newImageContext(ctx, getImageTimeout(source))
+		err := pushSource(imageCtx, client, policyContext, copyOptions, source)
+		cancel()
+		image.Duration = time.Since(start).Seconds()
 		if err != nil {
-			return fmt.Errorf("target exists: %w", err)
-		}
-		if !targetExists {
-			if err := client.Tag(ctx, source.Image(), source.TargetImage()); err != nil {
-				return fmt.Errorf("tag image: %w", err)
+			metrics.ImagesFailed.WithLabelValues(getMetricLabels(source)...).Inc()
+			report.add(image, actionFailed, "missing at target", err)
+			if err := failures.add(ctx, source.Image(), err); err != nil {
+				return err
 			}
+
+			continue
 		}
 
-		log.Infof("Pushing %s", source.TargetImage())
+		metrics.ImagesCopied.WithLabelValues(getMetricLabels(source)...).Inc()
+		metrics.CopyDuration.WithLabelValues(getMetricLabels(source)...).Observe(image.Duration)
+		report.add(image, actionCopied, "missing at target", nil)
+	}
+
+	if failures.failed() {
+		return failures.summary(len(sources))
+	}
+
+	metrics.LastSuccess.SetToCurrentTime()
+	log.Infof("All images have been pushed!")
+
+	return nil
+}
 
-		targetAuth, err := source.Target.EncodedAuth()
+// pushSource pushes the image of the source to its target through the Docker daemon,
+// pulling the image first when it does not exist on the Docker host.
+func pushSource(ctx context.Context, client docker.Client, policyContext *signature.PolicyContext, copyOptions copy.Options, source manifest.Source) error {
+	sourceExists, err := client.ImageExistsOnHost(ctx, source.Image())
+	if err != nil {
+		return fmt.Errorf("image exists: %w", err)
+	}
+
+	if !sourceExists {
+		sourceLogger(source, "pull").Info("Pulling image")
+
+		sourceAuth, err := source.EncodedAuth()
 		if err != nil {
-			return fmt.Errorf("get target auth: %w", err)
+			return fmt.Errorf("get source auth: %w", err)
 		}
-		if err := client.PushAndWait(ctx, source.TargetImage(), targetAuth); err != nil {
-			return fmt.Errorf("push image and wait: %w", err)
+		if err := client.PullAndWait(ctx, source.Image(), sourceAuth); err != nil {
+			return fmt.Errorf("pull image and wait: %w", err)
 		}
 	}
 
-	log.Infof("All images have been pushed!")
+	targetExists, err := client.ImageExistsOnHost(ctx, source.TargetImage())
+	if err != nil {
+		return fmt.Errorf("target exists: %w", err)
+	}
+	if !targetExists {
+		if err := client.Tag(ctx, source.Image(), source.TargetImage()); err != nil {
+			return fmt.Errorf("tag image: %w", err)
+		}
+	}
+
+	sourceLogger(source, "push").Info("Pushing image")
+
+	targetAuth, err := source.Target.EncodedAuth()
+	if err != nil {
+		return fmt.Errorf("get target auth: %w", err)
+	}
+	if err := client.PushAndWait(ctx, source.TargetImage(), targetAuth); err != nil {
+		return fmt.Errorf("push image and wait: %w", err)
+	}
+
+	if signingEnabled() {
+		sourceLogger(source, "sign").Info("Signing image")
+
+		if err := signImage(ctx, policyContext, source.TargetImage(), copyOptions); err != nil {
+			return fmt.Errorf("sign image: %w", err)
+		}
+	}
 
 	return nil
 }
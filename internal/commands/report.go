@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/plexsystems/sinker/internal/docker"
+
+	"github.com/spf13/viper"
+)
+
+// The actions that can be taken for an image of a sync. An image is copied when
+// it is copied to its destination, which is the target for copy and push, and
+// the Docker host for pull.
+const (
+	actionSkipped = "skipped"
+	actionCopied  = "copied"
+	actionFailed  = "failed"
+)
+
+// syncReport is the report of a copy, push or pull, which is written as JSON with the report flag.
+type syncReport struct {
+	Command  string        `json:"command"`
+	Started  time.Time     `json:"started"`
+	Duration float64       `json:"durationSeconds"`
+	Images   []imageReport `json:"images"`
+}
+
+// imageReport is the action taken for an image of a sync, and the reason it was taken.
+type imageReport struct {
+	Source       string  `json:"source"`
+	Target       string  `json:"target,omitempty"`
+	SourceDigest string  `json:"sourceDigest,omitempty"`
+	TargetDigest string  `json:"targetDigest,omitempty"`
+	Action       string  `json:"action"`
+	Reason       string  `json:"reason,omitempty"`
+	Duration     float64 `json:"durationSeconds"`
+	Bytes        int64   `json:"bytes,omitempty"`
+	Error        string  `json:"error,omitempty"`
+}
+
+func newSyncReport(command string) *syncReport {
+	report := syncReport{
+		Command: command,
+		Started: time.Now(),
+		Images:  []imageReport{},
+	}
+
+	return &report
+}
+
+// reportDigestTimeout is the maximum time to resolve a digest of an image of the report.
+const reportDigestTimeout = 30 * time.Second
+
+// add adds the image to the report with the action taken for it. The digests
+// of the image are not resolved until the sync has finished.
+func (r *syncReport) add(image imageReport, action string, reason string, err error) {
+	image.Action = action
+	image.Reason = reason
+	if err != nil {
+		image.Error = err.Error()
+	}
+
+	r.Images = append(r.Images, image)
+}
+
+// resolveDigests resolves the source and target digests of the images once the sync has
+// finished and the report is about to be written. Each digest is resolved with its own
+// timeout, so that the digests are also resolved when the sync ran out of time. Digests
+// that cannot be resolved are left empty.
+func (r *syncReport) resolveDigests(client docker.Client) {
+	if viper.GetString("report") == "" {
+		return
+	}
+
+	for i := range r.Images {
+		r.Images[i].SourceDigest = getReportDigest(client, r.Images[i].Source)
+		if r.Images[i].Target != "" {
+			r.Images[i].TargetDigest = getReportDigest(client, r.Images[i].Target)
+		}
+	}
+}
+
+func getReportDigest(client docker.Client, image string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), reportDigestTimeout)
+	defer cancel()
+
+	digest, err := client.GetDigest(ctx, image)
+	if err != nil {
+		return ""
+	}
+
+	return digest
+}
+
+// writeReport writes the report to the given path, when one is set.
+func writeReport(report *syncReport, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	report.Duration = time.Since(report.Started).Seconds()
+
+	contents, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+
+	if err := os.WriteFile(path, contents, os.ModePerm); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	return nil
+}
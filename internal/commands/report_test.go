@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	report := newSyncReport("copy")
+	report.add(imageReport{Source: "busybox:1.0.0", Target: "mycr.com/busybox:1.0.0"}, actionSkipped, "exists at target", nil)
+	report.add(imageReport{Source: "alpine:3.0.0", Target: "mycr.com/alpine:3.0.0", Bytes: 1024}, actionFailed, "missing at target", errors.New("unauthorized"))
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := writeReport(report, path); err != nil {
+		t.Fatal("write report:", err)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("read report:", err)
+	}
+
+	var actual syncReport
+	if err := json.Unmarshal(contents, &actual); err != nil {
+		t.Fatal("unmarshal report:", err)
+	}
+
+	if actual.Command != "copy" {
+		t.Errorf("expected command %s, actual %s", "copy", actual.Command)
+	}
+
+	expected := []imageReport{
+		{Source: "busybox:1.0.0", Target: "mycr.com/busybox:1.0.0", Action: actionSkipped, Reason: "exists at target"},
+		{Source: "alpine:3.0.0", Target: "mycr.com/alpine:3.0.0", Action: actionFailed, Reason: "missing at target", Bytes: 1024, Error: "unauthorized"},
+	}
+
+	if len(actual.Images) != len(expected) {
+		t.Fatalf("expected %d images, actual %d", len(expected), len(actual.Images))
+	}
+
+	for i := range expected {
+		if actual.Images[i] != expected[i] {
+			t.Errorf("expected image %v, actual %v", expected[i], actual.Images[i])
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/plexsystems/sinker/internal/docker"
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newRewriteCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "rewrite <path>",
+		Short: "Rewrite the images in Kubernetes manifests to their target images",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			flags := []string{"output", "pin"}
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runRewriteCommand(args[0]); err != nil {
+				return fmt.Errorf("rewrite: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "Directory to write the rewritten manifests to (defaults to rewriting the manifests in place)")
+	cmd.Flags().Bool("pin", false, "Pin the target images to the digest of the image at the target")
+
+	return &cmd
+}
+
+func runRewriteCommand(path string) error {
+	ctx, cancel := newTimeoutContext(context.Background())
+	defer cancel()
+
+	client, err := newDockerClient()
+	if err != nil {
+		return fmt.Errorf("new client: %w", err)
+	}
+
+	imageManifest, err := manifest.Get(viper.GetString("manifest"))
+	if err != nil {
+		return fmt.Errorf("get manifest: %w", err)
+	}
+
+	filePaths, err := manifest.GetKubernetesManifestPaths(path)
+	if err != nil {
+		return fmt.Errorf("get manifest paths: %w", err)
+	}
+
+	// The target image of each image is cached as the same image is
+	// usually found in more than one Kubernetes manifest.
+	targetImages := make(map[string]string)
+	for _, filePath := range filePaths {
+		contents, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("read file: %w", err)
+		}
+
+		images, err := manifest.GetImagesFromKubernetesResources([]string{string(contents)})
+		if err != nil {
+			return fmt.Errorf("get images from %s: %w", filePath, err)
+		}
+
+		imagesToRewrite := make(map[string]string)
+		for _, image := range images {
+			if _, exists := targetImages[image]; !exists {
+				targetImage, err := getRewrittenImage(ctx, client, imageManifest, image)
+				if err != nil {
+					return fmt.Errorf("get rewritten image: %w", err)
+				}
+
+				targetImages[image] = targetImage
+			}
+
+			if targetImages[image] != "" {
+				imagesToRewrite[image] = targetImages[image]
+			}
+		}
+
+		if len(imagesToRewrite) == 0 && viper.GetString("output") == "" {
+			continue
+		}
+
+		rewrittenContents, err := manifest.RewriteImages(contents, imagesToRewrite)
+		if err != nil {
+			return fmt.Errorf("rewrite images in %s: %w", filePath, err)
+		}
+
+		outputPath, err := getRewriteOutputPath(path, filePath, viper.GetString("output"))
+		if err != nil {
+			return fmt.Errorf("get output path: %w", err)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+			return fmt.Errorf("create output dir: %w", err)
+		}
+
+		if err := os.WriteFile(outputPath, rewrittenContents, os.ModePerm); err != nil {
+			return fmt.Errorf("write file: %w", err)
+		}
+
+		if len(imagesToRewrite) > 0 {
+			log.WithFields(log.Fields{"images": len(imagesToRewrite), "path": outputPath}).Info("Rewrote images")
+		}
+	}
+
+	log.Infof("All images have been rewritten!")
+	return nil
+}
+
+// getRewrittenImage returns the target image of the given image, pinned to the digest
+// of the target image when the pin flag is set. An empty image is returned when the
+// image is not a source in the manifest.
+func getRewrittenImage(ctx context.Context, client docker.Client, imageManifest manifest.Manifest, image string) (string, error) {
+	source, exists := imageManifest.FindSourceForImage(image)
+	if !exists {
+		imageLogger(image, "rewrite").Info("Image was not found in the manifest. Skipping ...")
+		return "", nil
+	}
+
+	// Sources with a digest are also resolved, as they are copied to a tag of the
+	// target named after the digest, and the copy can change the digest.
+	if !viper.GetBool("pin") {
+		return source.TargetImage(), nil
+	}
+
+	digest, err := client.GetDigest(ctx, source.TargetImage())
+	if err != nil {
+		return "", fmt.Errorf("get digest of %s: %w", source.TargetImage(), err)
+	}
+
+	return source.TargetImage() + "@" + digest, nil
+}
+
+// getRewriteOutputPath returns the path the rewritten file is written to. When no
+// output directory is given, the file is rewritten in place. Otherwise, the path of
+// the file relative to the input path is kept within the output directory.
+func getRewriteOutputPath(inputPath string, filePath string, outputDir string) (string, error) {
+	if outputDir == "" {
+		return filePath, nil
+	}
+
+	relativePath, err := filepath.Rel(inputPath, filePath)
+	if err != nil {
+		return "", fmt.Errorf("relative path: %w", err)
+	}
+
+	if relativePath == "." {
+		relativePath = filepath.Base(filePath)
+	}
+
+	return filepath.Join(outputDir, relativePath), nil
+}
@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGetRewriteOutputPath(t *testing.T) {
+	testCases := []struct {
+		inputPath string
+		filePath  string
+		outputDir string
+		expected  string
+	}{
+		{"deploy", filepath.Join("deploy", "app", "deployment.yaml"), "", filepath.Join("deploy", "app", "deployment.yaml")},
+		{"deploy", filepath.Join("deploy", "app", "deployment.yaml"), "out", filepath.Join("out", "app", "deployment.yaml")},
+		{filepath.Join("deploy", "pod.yaml"), filepath.Join("deploy", "pod.yaml"), "out", filepath.Join("out", "pod.yaml")},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := getRewriteOutputPath(testCase.inputPath, testCase.filePath, testCase.outputDir)
+		if err != nil {
+			t.Fatal("get rewrite output path:", err)
+		}
+
+		if actual != testCase.expected {
+			t.Errorf("expected output path %s, actual %s", testCase.expected, actual)
+		}
+	}
+}
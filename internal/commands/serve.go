@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+	"github.com/plexsystems/sinker/internal/webhook"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newServeCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "serve <server>",
+		Short: "Serve sinker as a long running server",
+	}
+
+	cmd.AddCommand(newServeWebhookCommand())
+
+	return &cmd
+}
+
+func newServeWebhookCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "webhook",
+		Short: "Serve a mutating admission webhook that replaces images with their target images",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			flags := []string{"addr", "tls-cert-file", "tls-key-file", "deny"}
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runServeWebhookCommand(); err != nil {
+				return fmt.Errorf("serve webhook: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("addr", ":8443", "Address to serve the webhook on")
+	cmd.Flags().String("tls-cert-file", "", "Path to the TLS certificate of the webhook")
+	cmd.Flags().String("tls-key-file", "", "Path to the TLS private key of the webhook")
+	cmd.Flags().Bool("deny", false, "Deny resources with images that are not mirrored")
+
+	cmd.MarkFlagRequired("tls-cert-file")
+	cmd.MarkFlagRequired("tls-key-file")
+
+	return &cmd
+}
+
+func runServeWebhookCommand() error {
+	imageManifest, err := manifest.Get(viper.GetString("manifest"))
+	if err != nil {
+		return fmt.Errorf("get manifest: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/mutate", webhook.New(imageManifest, viper.GetBool("deny"), log.StandardLogger()))
+	mux.HandleFunc("/healthz", func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+
+	server := http.Server{
+		Addr:              viper.GetString("addr"),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErrors := make(chan error, 1)
+	go func() {
+		serveErrors <- server.ListenAndServeTLS(viper.GetString("tls-cert-file"), viper.GetString("tls-key-file"))
+	}()
+
+	log.WithField("addr", server.Addr).Info("Serving webhook")
+
+	select {
+	case err := <-serveErrors:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("listen: %w", err)
+		}
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+
+	log.Infof("Webhook has been shut down")
+	return nil
+}
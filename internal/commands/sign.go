@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/containers/image/v5/copy"
+	dockerv5 "github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/signature"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// signingFlags are the flags used to sign the images written to the target. Images are
+// only signed with sigstore keys, as sinker is built with the openpgp implementation
+// of containers/image which does not support creating GPG signatures.
+var signingFlags = []string{"sign-by-sigstore-key", "sign-passphrase-file"}
+
+func addSigningFlags(cmd *cobra.Command) {
+	cmd.Flags().String("sign-by-sigstore-key", "", "Path to a sigstore (cosign) private key used to sign the images written to the target (signing with GPG keys is not supported)")
+	cmd.Flags().String("sign-passphrase-file", "", "Path to a file containing the passphrase of the signing key")
+}
+
+func signingEnabled() bool {
+	return viper.GetString("sign-by-sigstore-key") != ""
+}
+
+// setSigningOptions configures the copy options to sign the images
+// that are written to the target.
+func setSigningOptions(copyOptions *copy.Options) error {
+	var passphrase []byte
+	if viper.GetString("sign-passphrase-file") != "" {
+		contents, err := os.ReadFile(viper.GetString("sign-passphrase-file"))
+		if err != nil {
+			return fmt.Errorf("read passphrase file: %w", err)
+		}
+
+		passphrase = bytes.TrimRight(contents, "\r\n")
+	}
+
+	copyOptions.SignBySigstorePrivateKeyFile = viper.GetString("sign-by-sigstore-key")
+	copyOptions.SignSigstorePrivateKeyPassphrase = passphrase
+
+	return nil
+}
+
+// signImage signs an image that already exists in a registry by copying
+// the image onto itself with signing enabled.
+func signImage(ctx context.Context, policyContext *signature.PolicyContext, image string, copyOptions copy.Options) error {
+	imageRef, err := dockerv5.Transport.ParseReference(fmt.Sprintf("//%s", image))
+	if err != nil {
+		return fmt.Errorf("parse image reference: %w", err)
+	}
+
+	copyOptions.ImageListSelection = copy.CopyAllImages
+	copyOptions.PreserveDigests = true
+	if err := setSigningOptions(&copyOptions); err != nil {
+		return fmt.Errorf("set signing options: %w", err)
+	}
+
+	if _, err := copy.Image(ctx, policyContext, imageRef, imageRef, &copyOptions); err != nil {
+		return fmt.Errorf("copy image: %w", err)
+	}
+
+	return nil
+}
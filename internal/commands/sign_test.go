@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/copy"
+	"github.com/spf13/viper"
+)
+
+func TestSigningEnabled(t *testing.T) {
+	defer viper.Reset()
+
+	if signingEnabled() {
+		t.Errorf("expected signing to be disabled without a signing key")
+	}
+
+	viper.Set("sign-by-sigstore-key", "cosign.key")
+	if !signingEnabled() {
+		t.Errorf("expected signing to be enabled with a sigstore key")
+	}
+}
+
+func TestSetSigningOptions(t *testing.T) {
+	defer viper.Reset()
+
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase.txt")
+	if err := os.WriteFile(passphraseFile, []byte("secret\n"), os.ModePerm); err != nil {
+		t.Fatal("write passphrase file:", err)
+	}
+
+	viper.Set("sign-by-sigstore-key", "cosign.key")
+	viper.Set("sign-passphrase-file", passphraseFile)
+
+	var copyOptions copy.Options
+	if err := setSigningOptions(&copyOptions); err != nil {
+		t.Fatal("set signing options:", err)
+	}
+
+	if copyOptions.SignBySigstorePrivateKeyFile != "cosign.key" {
+		t.Errorf("expected sigstore key to be cosign.key, actual %s", copyOptions.SignBySigstorePrivateKeyFile)
+	}
+
+	if string(copyOptions.SignSigstorePrivateKeyPassphrase) != "secret" {
+		t.Errorf("expected passphrase without the trailing newline, actual %q", copyOptions.SignSigstorePrivateKeyPassphrase)
+	}
+
+	if copyOptions.SignBy != "" {
+		t.Errorf("expected no GPG key to be set, actual %s", copyOptions.SignBy)
+	}
+}
+
+func TestSetSigningOptionsMissingPassphraseFile(t *testing.T) {
+	defer viper.Reset()
+
+	viper.Set("sign-passphrase-file", filepath.Join(t.TempDir(), "missing.txt"))
+
+	var copyOptions copy.Options
+	if err := setSigningOptions(&copyOptions); err == nil {
+		t.Errorf("expected an error when the passphrase file does not exist")
+	}
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/plexsystems/sinker/internal/docker"
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// newTimeoutContext returns the context of a command, which is cancelled once the
+// timeout of the command has passed. A timeout of zero disables the timeout.
+func newTimeoutContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(parent, viper.GetDuration("timeout"))
+}
+
+// newImageContext returns the context to process a single image in. Each image has its own
+// timeout, so that a slow image fails on its own rather than using up the timeout of the command.
+func newImageContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return withTimeout(parent, timeout)
+}
+
+// getImageTimeout returns the timeout of the image of the source. The timeout
+// of the source takes precedence over the image timeout flag.
+func getImageTimeout(source manifest.Source) time.Duration {
+	if source.Timeout > 0 {
+		return source.Timeout
+	}
+
+	return viper.GetDuration("image-timeout")
+}
+
+func withTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, timeout)
+}
+
+// newDockerClient returns a Docker client whose requests to registries fail once the API
+// timeout has passed without a response. The timeout does not apply to the images copied
+// with containers/image or pushed and pulled by the Docker daemon, which have no such option.
+func newDockerClient() (docker.Client, error) {
+	client, err := docker.New(log.StandardLogger())
+	if err != nil {
+		return docker.Client{}, err
+	}
+
+	client, err = client.WithRequestTimeout(viper.GetDuration("api-timeout"))
+	if err != nil {
+		return docker.Client{}, fmt.Errorf("set api timeout: %w", err)
+	}
+
+	return client, nil
+}
@@ -0,0 +1,252 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/plexsystems/sinker/internal/docker"
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	verifyOK           = "ok"
+	verifyMissing      = "missing"
+	verifyDrifted      = "drifted"
+	verifyUnauthorized = "unauthorized"
+	verifyError        = "error"
+)
+
+// verifyResult is the result of verifying that a target matches its source.
+type verifyResult struct {
+	Source  string
+	Target  string
+	Status  string
+	Details string
+}
+
+func newVerifyCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "verify",
+		Short: "Verify that the images at the target match their source",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			flags := []string{"images", "target", "junit"}
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			if len(viper.GetStringSlice("images")) > 0 && viper.GetString("target") == "" {
+				return errors.New("target must be specified when using the images flag")
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runVerifyCommand(); err != nil {
+				return fmt.Errorf("verify: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceP("images", "i", []string{}, "List of images to verify")
+	cmd.Flags().StringP("target", "t", "", "Registry the images were copied to")
+	cmd.Flags().String("junit", "", "Path to write a JUnit XML report with a test case for each image to")
+
+	return &cmd
+}
+
+func runVerifyCommand() error {
+	start := time.Now()
+
+	// The JUnit report is also written when the verify fails, with
+	// a test case for each of the images verified before the failure.
+	var results []verifyResult
+	defer func() {
+		suite := newJUnitTestSuite("verify", time.Since(start), getVerifyTestCases(results))
+		if err := writeJUnit(suite, viper.GetString("junit")); err != nil {
+			log.WithError(err).Error("Unable to write JUnit report")
+		}
+	}()
+
+	ctx, cancel := newTimeoutContext(context.Background())
+	defer cancel()
+
+	client, err := newDockerClient()
+	if err != nil {
+		return fmt.Errorf("new client: %w", err)
+	}
+
+	var sources []manifest.Source
+	if len(viper.GetStringSlice("images")) > 0 {
+		sources = manifest.GetSourcesFromImages(viper.GetStringSlice("images"), viper.GetString("target"))
+	} else {
+		imageManifest, err := manifest.Get(viper.GetString("manifest"))
+		if err != nil {
+			return fmt.Errorf("get manifest: %w", err)
+		}
+
+		sources = imageManifest.Sources
+	}
+
+	for _, source := range sources {
+		imageCtx, cancel := newImageContext(ctx, getImageTimeout(source))
+		result, err := verifySource(imageCtx, client, source)
+		cancel()
+		if err != nil {
+			sourceLogger(source, "verify").WithError(err).Error("Unable to verify image")
+			result = verifyResult{
+				Source:  source.Image(),
+				Target:  source.TargetImage(),
+				Status:  verifyError,
+				Details: err.Error(),
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	if err := writeVerifyResults(results); err != nil {
+		return fmt.Errorf("write results: %w", err)
+	}
+
+	var failed int
+	for _, result := range results {
+		if result.Status != verifyOK {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images failed verification", failed, len(results))
+	}
+
+	return nil
+}
+
+func verifySource(ctx context.Context, client docker.Client, source manifest.Source) (verifyResult, error) {
+	result := verifyResult{
+		Source: source.Image(),
+		Target: source.TargetImage(),
+	}
+
+	sourceDigests, err := client.GetImageDigests(ctx, source.Image())
+	if docker.IsUnauthorizedError(err) {
+		result.Status = verifyUnauthorized
+		result.Details = "not authorized to access source"
+		return result, nil
+	}
+	if docker.IsNotFoundError(err) {
+		result.Status = verifyMissing
+		result.Details = "source not found"
+		return result, nil
+	}
+	if err != nil {
+		return verifyResult{}, fmt.Errorf("get source digests: %w", err)
+	}
+
+	targetDigests, err := client.GetImageDigests(ctx, source.TargetImage())
+	if docker.IsUnauthorizedError(err) {
+		result.Status = verifyUnauthorized
+		result.Details = "not authorized to access target"
+		return result, nil
+	}
+	if docker.IsNotFoundError(err) {
+		result.Status = verifyMissing
+		result.Details = "target not found"
+		return result, nil
+	}
+	if err != nil {
+		return verifyResult{}, fmt.Errorf("get target digests: %w", err)
+	}
+
+	driftedPlatforms := getDriftedPlatforms(sourceDigests, targetDigests, getCompressionFormat(source) != "")
+	if len(driftedPlatforms) > 0 {
+		result.Status = verifyDrifted
+		result.Details = strings.Join(driftedPlatforms, ", ")
+		return result, nil
+	}
+
+	result.Status = verifyOK
+	return result, nil
+}
+
+// getDriftedPlatforms returns the platforms of the target whose digest does not
+// match the digest of the same platform at the source.
+//
+// A target that is a single image only needs to match the platform it was copied
+// from, and does not drift when it is the manifest of one of the platforms of the
+// source, while a target that is an image index must contain every platform of the source.
+// Recompressing the layers changes the digests of the target, so when the target is
+// recompressed only the platforms are compared.
+func getDriftedPlatforms(source docker.ImageDigests, target docker.ImageDigests, recompressed bool) []string {
+	if source.Digest == target.Digest {
+		return nil
+	}
+
+	if !target.Index {
+		for _, digest := range source.Platforms {
+			if digest == target.Digest {
+				return nil
+			}
+		}
+	}
+
+	var driftedPlatforms []string
+	for platform, digest := range target.Platforms {
+		sourceDigest, exists := source.Platforms[platform]
+		if !exists || (!recompressed && sourceDigest != digest) {
+			driftedPlatforms = append(driftedPlatforms, getPlatformName(platform))
+		}
+	}
+
+	if target.Index {
+		for platform := range source.Platforms {
+			if _, exists := target.Platforms[platform]; !exists {
+				driftedPlatforms = append(driftedPlatforms, getPlatformName(platform))
+			}
+		}
+	}
+
+	sort.Strings(driftedPlatforms)
+	return driftedPlatforms
+}
+
+func getPlatformName(platform string) string {
+	if platform == "" {
+		return "manifest"
+	}
+
+	return platform
+}
+
+func writeVerifyResults(results []verifyResult) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(writer, "STATUS\tSOURCE\tTARGET\tDETAILS"); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	for _, result := range results {
+		if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", result.Status, result.Source, result.Target, result.Details); err != nil {
+			return fmt.Errorf("write result: %w", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+
+	return nil
+}
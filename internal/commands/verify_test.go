@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/plexsystems/sinker/internal/docker"
+)
+
+func TestGetDriftedPlatforms(t *testing.T) {
+	source := docker.ImageDigests{
+		Digest: "sha256:index",
+		Index:  true,
+		Platforms: map[string]string{
+			"linux/amd64": "sha256:amd64",
+			"linux/arm64": "sha256:arm64",
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		target       docker.ImageDigests
+		recompressed bool
+		expected     []string
+	}{
+		{
+			name:   "same index",
+			target: source,
+		},
+		{
+			name: "single platform copied",
+			target: docker.ImageDigests{
+				Digest:    "sha256:amd64",
+				Platforms: map[string]string{"linux/amd64": "sha256:amd64"},
+			},
+		},
+		{
+			name: "single platform copied with a different platform key",
+			target: docker.ImageDigests{
+				Digest:    "sha256:arm64",
+				Platforms: map[string]string{"linux/arm64/v8": "sha256:arm64"},
+			},
+		},
+		{
+			name: "single platform drifted",
+			target: docker.ImageDigests{
+				Digest:    "sha256:other",
+				Platforms: map[string]string{"linux/amd64": "sha256:other"},
+			},
+			expected: []string{"linux/amd64"},
+		},
+		{
+			name: "index missing platform",
+			target: docker.ImageDigests{
+				Digest:    "sha256:partial",
+				Index:     true,
+				Platforms: map[string]string{"linux/amd64": "sha256:amd64"},
+			},
+			expected: []string{"linux/arm64"},
+		},
+		{
+			name: "artifact drifted",
+			target: docker.ImageDigests{
+				Digest:    "sha256:artifact",
+				Platforms: map[string]string{"": "sha256:artifact"},
+			},
+			expected: []string{"manifest"},
+		},
+		{
+			name: "recompressed index",
+			target: docker.ImageDigests{
+				Digest:    "sha256:recompressed",
+				Index:     true,
+				Platforms: map[string]string{"linux/amd64": "sha256:zstd-amd64", "linux/arm64": "sha256:zstd-arm64"},
+			},
+			recompressed: true,
+		},
+		{
+			name: "recompressed index missing platform",
+			target: docker.ImageDigests{
+				Digest:    "sha256:recompressed",
+				Index:     true,
+				Platforms: map[string]string{"linux/amd64": "sha256:zstd-amd64"},
+			},
+			recompressed: true,
+			expected:     []string{"linux/arm64"},
+		},
+		{
+			name: "recompressed single platform not in source",
+			target: docker.ImageDigests{
+				Digest:    "sha256:recompressed",
+				Platforms: map[string]string{"linux/s390x": "sha256:zstd-s390x"},
+			},
+			recompressed: true,
+			expected:     []string{"linux/s390x"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		actual := getDriftedPlatforms(source, testCase.target, testCase.recompressed)
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("%s: expected drifted platforms %v, actual %v", testCase.name, testCase.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	"github.com/hashicorp/go-version"
+)
+
+// calverPattern matches calendar versions such as 2023.10.01 or 2023-10-01.
+var calverPattern = regexp.MustCompile(`^v?\d{2,4}([._-]\d+)+$`)
+
+// allowedPreReleases are the pre-releases that are included
+// when the versioning does not configure pre-releases.
+var allowedPreReleases = []string{"alpha", "beta", "rc"}
+
+// versionScheme parses the tags of a source into versions that can be compared.
+type versionScheme struct {
+	scheme     string
+	pattern    *regexp.Regexp
+	order      []int
+	variant    string
+	preRelease *bool
+}
+
+func newVersionScheme(versioning manifest.Versioning) (versionScheme, error) {
+	scheme := versionScheme{
+		scheme:     versioning.Scheme,
+		order:      versioning.Order,
+		variant:    versioning.Variant,
+		preRelease: versioning.PreRelease,
+	}
+
+	switch versioning.Scheme {
+	case "", "semver", "calver":
+	case "regex":
+		if versioning.Pattern == "" {
+			return versionScheme{}, errors.New("regex scheme requires a pattern")
+		}
+
+		// The pattern is grouped so that the anchors apply to every alternative of the pattern.
+		pattern, err := regexp.Compile("^(?:" + versioning.Pattern + ")$")
+		if err != nil {
+			return versionScheme{}, fmt.Errorf("compile pattern: %w", err)
+		}
+
+		for _, group := range versioning.Order {
+			if group < 1 || group > pattern.NumSubexp() {
+				return versionScheme{}, fmt.Errorf("order %d is not a capture group of the pattern", group)
+			}
+		}
+
+		scheme.pattern = pattern
+	default:
+		return versionScheme{}, fmt.Errorf("unknown scheme %s (must be semver, calver or regex)", versioning.Scheme)
+	}
+
+	return scheme, nil
+}
+
+// parse returns the version of the tag. An error is returned when the
+// tag is not a version of the scheme, or does not have the variant.
+func (s versionScheme) parse(tag string) (*version.Version, error) {
+	if s.variant != "" {
+		if !strings.HasSuffix(tag, s.variant) {
+			return nil, fmt.Errorf("tag %s does not have variant %s", tag, s.variant)
+		}
+
+		tag = strings.TrimSuffix(tag, s.variant)
+	}
+
+	switch s.scheme {
+	case "calver":
+		if !calverPattern.MatchString(tag) {
+			return nil, fmt.Errorf("tag %s is not a calendar version", tag)
+		}
+
+		return version.NewVersion(strings.NewReplacer("-", ".", "_", ".").Replace(tag))
+	case "regex":
+		matches := s.pattern.FindStringSubmatch(tag)
+		if matches == nil {
+			return nil, fmt.Errorf("tag %s does not match pattern", tag)
+		}
+
+		segments := matches[1:]
+		if len(s.order) > 0 {
+			segments = nil
+			for _, group := range s.order {
+				segments = append(segments, matches[group])
+			}
+		}
+
+		return version.NewVersion(strings.Join(segments, "."))
+	default:
+		return version.NewVersion(tag)
+	}
+}
+
+// filter returns the tags that are versions of the scheme.
+func (s versionScheme) filter(tags []string) []string {
+	if s.isDefault() {
+		return filterTags(tags)
+	}
+
+	var filteredTags []string
+	for _, tag := range tags {
+		tagVersion, err := s.parse(tag)
+		if err != nil {
+			continue
+		}
+
+		if tagVersion.Prerelease() != "" && !s.allowsPreRelease(tagVersion.Prerelease()) {
+			continue
+		}
+
+		filteredTags = append(filteredTags, tag)
+	}
+
+	return filteredTags
+}
+
+func (s versionScheme) allowsPreRelease(preRelease string) bool {
+	if s.preRelease == nil {
+		return containsSubstring(allowedPreReleases, preRelease)
+	}
+
+	return *s.preRelease
+}
+
+// includesPreReleases returns true when the scheme is configured to include every pre-release.
+func (s versionScheme) includesPreReleases() bool {
+	return s.preRelease != nil && *s.preRelease
+}
+
+// isDefault returns true when the scheme has not been configured, in which
+// case the tags are filtered to strict semantic versions.
+func (s versionScheme) isDefault() bool {
+	return (s.scheme == "" || s.scheme == "semver") && s.variant == "" && s.preRelease == nil
+}
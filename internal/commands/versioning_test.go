@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+)
+
+func TestVersionScheme_Filter(t *testing.T) {
+	includePreReleases := true
+	excludePreReleases := false
+
+	testCases := []struct {
+		desc       string
+		versioning manifest.Versioning
+		tags       []string
+		expected   []string
+	}{
+		{
+			desc:       "default semver",
+			versioning: manifest.Versioning{},
+			tags:       []string{"1.0.0", "1.1.0-rc.1", "1.1.0-alpine", "2023.10.01"},
+			expected:   []string{"1.0.0", "1.1.0-rc.1"},
+		},
+		{
+			desc:       "semver variant",
+			versioning: manifest.Versioning{Variant: "-alpine"},
+			tags:       []string{"1.25", "1.25-alpine", "1.26-alpine", "1.26-bullseye"},
+			expected:   []string{"1.25-alpine", "1.26-alpine"},
+		},
+		{
+			desc:       "semver excluding pre-releases",
+			versioning: manifest.Versioning{PreRelease: &excludePreReleases},
+			tags:       []string{"1.0.0", "1.1.0-rc.1"},
+			expected:   []string{"1.0.0"},
+		},
+		{
+			desc:       "semver including pre-releases",
+			versioning: manifest.Versioning{PreRelease: &includePreReleases},
+			tags:       []string{"1.0.0", "1.1.0-nightly.1"},
+			expected:   []string{"1.0.0", "1.1.0-nightly.1"},
+		},
+		{
+			desc:       "calver",
+			versioning: manifest.Versioning{Scheme: "calver"},
+			tags:       []string{"2023.10.01", "2023-11-01", "latest", "1.0.0"},
+			expected:   []string{"2023.10.01", "2023-11-01"},
+		},
+		{
+			desc: "regex",
+			versioning: manifest.Versioning{
+				Scheme:  "regex",
+				Pattern: `RELEASE\.(\d+)-(\d+)-(\d+)T(\d+)-(\d+)-(\d+)Z`,
+			},
+			tags:     []string{"RELEASE.2023-10-01T00-00-00Z", "latest", "RELEASE.2023-10-01"},
+			expected: []string{"RELEASE.2023-10-01T00-00-00Z"},
+		},
+		{
+			desc: "regex with alternatives",
+			versioning: manifest.Versioning{
+				Scheme:  "regex",
+				Pattern: `(\d+)\.(\d+)|stable`,
+			},
+			tags:     []string{"1.2", "1.2-debug", "stable"},
+			expected: []string{"1.2"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		scheme, err := newVersionScheme(testCase.versioning)
+		if err != nil {
+			t.Fatal("new version scheme:", err)
+		}
+
+		actual := scheme.filter(testCase.tags)
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("%s: expected tags %v, actual %v", testCase.desc, testCase.expected, actual)
+		}
+	}
+}
+
+func TestVersionScheme_RegexOrder(t *testing.T) {
+	scheme, err := newVersionScheme(manifest.Versioning{
+		Scheme:  "regex",
+		Pattern: `build-(\d+)-(\d+)`,
+		Order:   []int{2, 1},
+	})
+	if err != nil {
+		t.Fatal("new version scheme:", err)
+	}
+
+	currentVersion, err := scheme.parse("build-9-2023")
+	if err != nil {
+		t.Fatal("parse:", err)
+	}
+
+	actual := getAllNewerVersions(currentVersion, []string{"build-1-2024", "build-10-2022", "build-10-2023"}, scheme)
+	expected := []string{"build-1-2024", "build-10-2023"}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected newer versions %v, actual %v", expected, actual)
+	}
+}
+
+func TestNewVersionScheme_Invalid(t *testing.T) {
+	invalidVersionings := []manifest.Versioning{
+		{Scheme: "unknown"},
+		{Scheme: "regex"},
+		{Scheme: "regex", Pattern: `(\d+)`, Order: []int{2}},
+	}
+
+	for _, versioning := range invalidVersionings {
+		if _, err := newVersionScheme(versioning); err == nil {
+			t.Errorf("expected versioning %v to be invalid", versioning)
+		}
+	}
+}
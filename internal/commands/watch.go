@@ -0,0 +1,239 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// initialBackoff is how long to wait before syncing again after the first failed sync.
+// The wait doubles with each failed sync that follows, up to the max backoff.
+const initialBackoff = 30 * time.Second
+
+// manifestChangeDelay is how long to wait for more changes to the manifest before
+// syncing, as editors usually write a file with more than one operation.
+const manifestChangeDelay = time.Second
+
+func newWatchCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "watch",
+		Short: "Continuously copy the images in the manifest to the target on a schedule and when the manifest changes",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			flags := []string{"interval", "schedule", "max-backoff", "metrics-addr", "metrics-textfile"}
+			flags = append(flags, copyFlags...)
+			flags = append(flags, signingFlags...)
+			flags = append(flags, failureFlags...)
+			for _, flag := range flags {
+				if err := viper.BindPFlag(flag, cmd.Flags().Lookup(flag)); err != nil {
+					return fmt.Errorf("bind flag: %w", err)
+				}
+			}
+
+			if viper.GetDuration("interval") <= 0 {
+				return errors.New("interval must be greater than zero")
+			}
+
+			if _, err := getSyncSchedule(); err != nil {
+				return fmt.Errorf("get schedule: %w", err)
+			}
+
+			if err := validateCopyFlags(); err != nil {
+				return err
+			}
+
+			if err := validateFailureFlags(); err != nil {
+				return err
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := runWatchCommand(); err != nil {
+				return fmt.Errorf("watch: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Duration("interval", 5*time.Minute, "Interval to copy the images at")
+	cmd.Flags().String("schedule", "", "Cron schedule to copy the images on (e.g. '0 * * * *'), which takes precedence over the interval")
+	cmd.Flags().Duration("max-backoff", 30*time.Minute, "Maximum time to wait before copying the images again after a failure")
+	cmd.Flags().String("metrics-addr", "", "Address to serve the Prometheus metrics at /metrics on (e.g. :9090)")
+	cmd.Flags().String("metrics-textfile", "", "Path to write the Prometheus metrics to after each copy for the node exporter textfile collector")
+	addCopyFlags(&cmd)
+	addSigningFlags(&cmd)
+	addFailureFlags(&cmd)
+
+	return &cmd
+}
+
+func runWatchCommand() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	schedule, err := getSyncSchedule()
+	if err != nil {
+		return fmt.Errorf("get schedule: %w", err)
+	}
+
+	manifestPath := manifest.Location(viper.GetString("manifest"))
+	manifestChanges, err := watchManifest(ctx, manifestPath)
+	if err != nil {
+		return fmt.Errorf("watch manifest: %w", err)
+	}
+
+	stopMetrics := serveMetrics()
+	defer stopMetrics()
+
+	log.WithField("manifest", manifestPath).Info("Watching manifest for changes")
+
+	var failures int
+	for {
+		syncCtx, cancel := newTimeoutContext(ctx)
+		err := runCopyCommand(syncCtx, newSyncReport("watch"))
+		cancel()
+
+		if err := writeMetricsTextfile(); err != nil {
+			log.WithError(err).Error("Unable to write metrics")
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		var wait time.Duration
+		if err != nil {
+			failures++
+			wait = getBackoff(failures, viper.GetDuration("max-backoff"))
+			log.WithError(err).Error("Unable to copy images")
+			log.WithFields(log.Fields{"wait": wait.String(), "attempt": failures}).Info("Retrying copy after failure")
+		} else {
+			failures = 0
+			next := schedule.Next(time.Now())
+			wait = time.Until(next)
+			log.WithField("next", next.Format(time.RFC3339)).Info("Waiting for next copy")
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		case <-manifestChanges:
+			log.WithField("manifest", manifestPath).Info("Manifest changed")
+		}
+		timer.Stop()
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	log.WithField("manifest", manifestPath).Info("Stopped watching manifest")
+	return nil
+}
+
+// getSyncSchedule returns the schedule to copy the images on, which is the
+// cron schedule when one is set, and the interval otherwise.
+func getSyncSchedule() (cron.Schedule, error) {
+	if viper.GetString("schedule") == "" {
+		return cron.Every(viper.GetDuration("interval")), nil
+	}
+
+	schedule, err := cron.ParseStandard(viper.GetString("schedule"))
+	if err != nil {
+		return nil, fmt.Errorf("parse schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// getBackoff returns how long to wait before syncing again after the given number of
+// consecutive failed syncs. The backoff doubles with each failure up to the max backoff.
+func getBackoff(failures int, maxBackoff time.Duration) time.Duration {
+	backoff := initialBackoff
+	for i := 1; i < failures && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+
+	return backoff
+}
+
+// watchManifest returns a channel that receives a value when the manifest at the given path
+// changes. The directory of the manifest is watched rather than the manifest itself, so
+// that changes are still seen when the manifest is replaced instead of written to.
+func watchManifest(ctx context.Context, path string) (<-chan struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("new watcher: %w", err)
+	}
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("add watcher: %w", err)
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer watcher.Close()
+
+		// Changes are only sent once no more changes have been seen for a while.
+		delay := time.NewTimer(manifestChangeDelay)
+		delay.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-watcher.Events:
+				if isManifestChange(event, path) {
+					delay.Reset(manifestChangeDelay)
+				}
+			case err := <-watcher.Errors:
+				log.WithField("manifest", path).WithError(err).Error("Unable to watch manifest")
+			case <-delay.C:
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+// isManifestChange returns true when the event changes the manifest at the given path.
+func isManifestChange(event fsnotify.Event, path string) bool {
+	if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+		return false
+	}
+
+	// A ConfigMap mounted in a Kubernetes pod is updated by replacing the
+	// ..data symlink that the manifest links to, rather than the manifest.
+	eventName := filepath.Base(event.Name)
+	if strings.HasPrefix(eventName, "..data") {
+		return true
+	}
+
+	return eventName == filepath.Base(path)
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestGetBackoff(t *testing.T) {
+	testCases := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 10 * time.Minute},
+	}
+
+	for _, testCase := range testCases {
+		actual := getBackoff(testCase.failures, 10*time.Minute)
+		if actual != testCase.expected {
+			t.Errorf("expected backoff after %d failures to be %s, actual %s", testCase.failures, testCase.expected, actual)
+		}
+	}
+}
+
+func TestIsManifestChange(t *testing.T) {
+	testCases := []struct {
+		event    fsnotify.Event
+		expected bool
+	}{
+		{fsnotify.Event{Name: "config/.images.yaml", Op: fsnotify.Write}, true},
+		{fsnotify.Event{Name: "config/.images.yaml", Op: fsnotify.Create}, true},
+		{fsnotify.Event{Name: "config/.images.yaml", Op: fsnotify.Chmod}, false},
+		{fsnotify.Event{Name: "config/other.yaml", Op: fsnotify.Write}, false},
+		{fsnotify.Event{Name: "config/..data", Op: fsnotify.Create}, true},
+	}
+
+	for _, testCase := range testCases {
+		actual := isManifestChange(testCase.event, "config/.images.yaml")
+		if actual != testCase.expected {
+			t.Errorf("expected %s to be a change of the manifest to be %v, actual %v", testCase.event, testCase.expected, actual)
+		}
+	}
+}
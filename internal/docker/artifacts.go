@@ -0,0 +1,221 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// attachedTagSuffixes are the suffixes of the tags that cosign uses to
+// attach signatures, attestations and SBOMs to an image.
+var attachedTagSuffixes = []string{".sig", ".att", ".sbom"}
+
+// CopyAttachedArtifacts copies the signatures, attestations, SBOMs and OCI referrers
+// attached to the source image so that they are attached to the target image. Artifacts
+// that already exist at the target are not copied again.
+//
+// The signatures and attestations sign the digest of the source image, so they only verify
+// against the target image when it has the same digest, which is expected to be preserved
+// by the copy. It returns the number of artifacts copied.
+func (c Client) CopyAttachedArtifacts(ctx context.Context, sourceImage string, targetImage string) (int, error) {
+	options := c.remoteOptions(ctx)
+
+	sourceReference, err := name.ParseReference(sourceImage, name.WeakValidation)
+	if err != nil {
+		return 0, fmt.Errorf("parse source ref: %w", err)
+	}
+
+	targetReference, err := name.ParseReference(targetImage, name.WeakValidation)
+	if err != nil {
+		return 0, fmt.Errorf("parse target ref: %w", err)
+	}
+
+	sourceDescriptor, err := remote.Head(sourceReference, options...)
+	if err != nil {
+		return 0, fmt.Errorf("head source: %w", err)
+	}
+
+	sourceRepository := sourceReference.Context()
+	targetRepository := targetReference.Context()
+
+	var references []string
+	for _, suffix := range attachedTagSuffixes {
+		references = append(references, ":"+getAttachedTag(sourceDescriptor.Digest, suffix))
+	}
+
+	referrers, err := remote.Referrers(sourceRepository.Digest(sourceDescriptor.Digest.String()), options...)
+	if err != nil && !IsNotFoundError(err) {
+		return 0, fmt.Errorf("get referrers: %w", err)
+	}
+	if referrers != nil {
+		for _, referrer := range referrers.Manifests {
+			references = append(references, "@"+referrer.Digest.String())
+		}
+	}
+
+	var copied int
+	for _, reference := range references {
+		sourceArtifact, err := name.ParseReference(sourceRepository.String()+reference, name.WeakValidation)
+		if err != nil {
+			return 0, fmt.Errorf("parse source artifact ref: %w", err)
+		}
+
+		artifactDescriptor, err := remote.Head(sourceArtifact, options...)
+		if IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("head %s: %w", sourceArtifact, err)
+		}
+
+		targetArtifact, err := name.ParseReference(targetRepository.String()+reference, name.WeakValidation)
+		if err != nil {
+			return 0, fmt.Errorf("parse target artifact ref: %w", err)
+		}
+
+		existingDescriptor, err := remote.Head(targetArtifact, options...)
+		if err != nil && !IsNotFoundError(err) {
+			return 0, fmt.Errorf("head %s: %w", targetArtifact, err)
+		}
+		if err == nil && existingDescriptor.Digest == artifactDescriptor.Digest {
+			continue
+		}
+
+		artifact, err := remote.Get(sourceArtifact, options...)
+		if err != nil {
+			return 0, fmt.Errorf("get %s: %w", sourceArtifact, err)
+		}
+
+		if err := writeDescriptor(targetArtifact, artifact, options...); err != nil {
+			return 0, fmt.Errorf("write %s: %w", targetArtifact, err)
+		}
+
+		copied++
+	}
+
+	return copied, nil
+}
+
+// CopyArtifact copies an OCI artifact, such as a Helm chart, from the source to the target
+// exactly as it exists at the source. An error is returned when the artifact found at the
+// source is not of the given artifact type.
+func (c Client) CopyArtifact(ctx context.Context, source string, target string, artifactType string) error {
+	options := c.remoteOptions(ctx)
+
+	sourceReference, err := name.ParseReference(source, name.WeakValidation)
+	if err != nil {
+		return fmt.Errorf("parse source ref: %w", err)
+	}
+
+	targetReference, err := name.ParseReference(target, name.WeakValidation)
+	if err != nil {
+		return fmt.Errorf("parse target ref: %w", err)
+	}
+
+	artifact, err := remote.Get(sourceReference, options...)
+	if err != nil {
+		return fmt.Errorf("get artifact: %w", err)
+	}
+
+	foundType, err := getArtifactType(artifact.Manifest)
+	if err != nil {
+		return fmt.Errorf("get artifact type: %w", err)
+	}
+
+	if foundType != artifactType {
+		return fmt.Errorf("expected artifact type %s, found %s", artifactType, foundType)
+	}
+
+	if err := writeDescriptor(targetReference, artifact, options...); err != nil {
+		return fmt.Errorf("write artifact: %w", err)
+	}
+
+	return nil
+}
+
+func (c Client) remoteOptions(ctx context.Context) []remote.Option {
+	return []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		remote.WithTransport(c.transport),
+	}
+}
+
+// writeDescriptor writes the image or image index of the descriptor, as is, to the reference.
+func writeDescriptor(reference name.Reference, descriptor *remote.Descriptor, options ...remote.Option) error {
+	if isIndexMediaType(descriptor.MediaType) {
+		index, err := descriptor.ImageIndex()
+		if err != nil {
+			return fmt.Errorf("image index: %w", err)
+		}
+
+		if err := remote.WriteIndex(reference, index, options...); err != nil {
+			return fmt.Errorf("write index: %w", err)
+		}
+
+		return nil
+	}
+
+	image, err := descriptor.Image()
+	if err != nil {
+		return fmt.Errorf("image: %w", err)
+	}
+
+	if err := remote.Write(reference, image, options...); err != nil {
+		return fmt.Errorf("write image: %w", err)
+	}
+
+	return nil
+}
+
+// getArtifactType returns the type of the artifact described by the manifest. This is
+// the artifactType of the manifest when set, otherwise the media type of its config.
+func getArtifactType(rawManifest []byte) (string, error) {
+	var artifactManifest struct {
+		ArtifactType string `json:"artifactType"`
+		Config       struct {
+			MediaType string `json:"mediaType"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(rawManifest, &artifactManifest); err != nil {
+		return "", fmt.Errorf("unmarshal manifest: %w", err)
+	}
+
+	if artifactManifest.ArtifactType != "" {
+		return artifactManifest.ArtifactType, nil
+	}
+
+	return artifactManifest.Config.MediaType, nil
+}
+
+// getAttachedTag returns the tag cosign uses to attach an artifact to the given digest
+// (e.g. sha256-<hex>.sig).
+func getAttachedTag(digest v1.Hash, suffix string) string {
+	return strings.Replace(digest.String(), ":", "-", 1) + suffix
+}
+
+// IsAttachedTag returns true if the tag is used by cosign to attach an artifact to an image.
+func IsAttachedTag(tag string) bool {
+	if !strings.HasPrefix(tag, "sha256-") {
+		return false
+	}
+
+	for _, suffix := range attachedTagSuffixes {
+		if strings.HasSuffix(tag, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isIndexMediaType(mediaType types.MediaType) bool {
+	return mediaType == types.OCIImageIndex || mediaType == types.DockerManifestList
+}
@@ -0,0 +1,130 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestGetAttachedTag(t *testing.T) {
+	digest := v1.Hash{
+		Algorithm: "sha256",
+		Hex:       "abc123",
+	}
+
+	actual := getAttachedTag(digest, ".sig")
+	expected := "sha256-abc123.sig"
+
+	if actual != expected {
+		t.Errorf("expected attached tag %s, actual %s", expected, actual)
+	}
+}
+
+func TestGetArtifactType(t *testing.T) {
+	testCases := []struct {
+		manifest string
+		expected string
+	}{
+		{
+			`{"config":{"mediaType":"application/vnd.cncf.helm.config.v1+json"}}`,
+			"application/vnd.cncf.helm.config.v1+json",
+		},
+		{
+			`{"artifactType":"application/wasm","config":{"mediaType":"application/vnd.oci.empty.v1+json"}}`,
+			"application/wasm",
+		},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := getArtifactType([]byte(testCase.manifest))
+		if err != nil {
+			t.Fatal("get artifact type:", err)
+		}
+
+		if actual != testCase.expected {
+			t.Errorf("expected artifact type %s, actual %s", testCase.expected, actual)
+		}
+	}
+}
+
+func TestIsAttachedTag(t *testing.T) {
+	testCases := []struct {
+		tag      string
+		expected bool
+	}{
+		{"sha256-9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.sig", true},
+		{"sha256-9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.att", true},
+		{"sha256-9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.sbom", true},
+		{"sha256-9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", false},
+		{"v1.0.0", false},
+	}
+
+	for _, testCase := range testCases {
+		actual := IsAttachedTag(testCase.tag)
+		if actual != testCase.expected {
+			t.Errorf("expected attached tag %s to be %v, actual %v", testCase.tag, testCase.expected, actual)
+		}
+	}
+}
+
+func TestCopyAttachedArtifacts(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := Client{transport: http.DefaultTransport}
+
+	image := writeRandomImage(t, host+"/source/app:1.0.0")
+	digest, err := image.Digest()
+	if err != nil {
+		t.Fatal("digest:", err)
+	}
+
+	writeRandomImage(t, host+"/source/app:"+getAttachedTag(digest, ".sig"))
+	writeImage(t, host+"/mirror/app:1.0.0", image)
+
+	copied, err := client.CopyAttachedArtifacts(context.Background(), host+"/source/app:1.0.0", host+"/mirror/app:1.0.0")
+	if err != nil {
+		t.Fatal("copy attached artifacts:", err)
+	}
+	if copied != 1 {
+		t.Errorf("expected 1 artifact to be copied, actual %d", copied)
+	}
+
+	copied, err = client.CopyAttachedArtifacts(context.Background(), host+"/source/app:1.0.0", host+"/mirror/app:1.0.0")
+	if err != nil {
+		t.Fatal("copy attached artifacts again:", err)
+	}
+	if copied != 0 {
+		t.Errorf("expected existing artifacts to not be copied again, actual %d copied", copied)
+	}
+}
+
+func writeRandomImage(t *testing.T, reference string) v1.Image {
+	image, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal("random image:", err)
+	}
+
+	writeImage(t, reference, image)
+	return image
+}
+
+func writeImage(t *testing.T, reference string, image v1.Image) {
+	parsedReference, err := name.ParseReference(reference)
+	if err != nil {
+		t.Fatal("parse reference:", err)
+	}
+
+	if err := remote.Write(parsedReference, image); err != nil {
+		t.Fatal("write image:", err)
+	}
+}
@@ -0,0 +1,122 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// ImageDigests are the digests of an image in a registry.
+type ImageDigests struct {
+	// Digest is the digest of the manifest, or image index, of the image.
+	Digest string
+
+	// Index is true when the image is an image index (e.g. a multi-arch image).
+	Index bool
+
+	// Platforms are the digests of the image manifests keyed by their platform
+	// (e.g. linux/amd64 or linux/arm64/v8). When the platform of a manifest is not known,
+	// such as for non-image artifacts, the manifest is keyed by an empty platform.
+	Platforms map[string]string
+}
+
+// GetImageDigests returns the digests of the image in the remote registry.
+func (c Client) GetImageDigests(ctx context.Context, image string) (ImageDigests, error) {
+	reference, err := name.ParseReference(image, name.WeakValidation)
+	if err != nil {
+		return ImageDigests{}, fmt.Errorf("parse ref: %w", err)
+	}
+
+	descriptor, err := remote.Get(reference, c.remoteOptions(ctx)...)
+	if err != nil {
+		return ImageDigests{}, fmt.Errorf("get image: %w", err)
+	}
+
+	imageDigests := ImageDigests{
+		Digest:    descriptor.Digest.String(),
+		Index:     isIndexMediaType(descriptor.MediaType),
+		Platforms: make(map[string]string),
+	}
+
+	if imageDigests.Index {
+		index, err := descriptor.ImageIndex()
+		if err != nil {
+			return ImageDigests{}, fmt.Errorf("image index: %w", err)
+		}
+
+		indexManifest, err := index.IndexManifest()
+		if err != nil {
+			return ImageDigests{}, fmt.Errorf("index manifest: %w", err)
+		}
+
+		for _, manifest := range indexManifest.Manifests {
+			imageDigests.Platforms[getPlatformKey(manifest.Platform)] = manifest.Digest.String()
+		}
+
+		return imageDigests, nil
+	}
+
+	isImage, err := hasImageConfig(descriptor.Manifest)
+	if err != nil {
+		return ImageDigests{}, fmt.Errorf("has image config: %w", err)
+	}
+
+	if !isImage {
+		imageDigests.Platforms[""] = imageDigests.Digest
+		return imageDigests, nil
+	}
+
+	remoteImage, err := descriptor.Image()
+	if err != nil {
+		return ImageDigests{}, fmt.Errorf("image: %w", err)
+	}
+
+	configFile, err := remoteImage.ConfigFile()
+	if err != nil {
+		return ImageDigests{}, fmt.Errorf("config file: %w", err)
+	}
+
+	imageDigests.Platforms[getPlatformKey(configFile.Platform())] = imageDigests.Digest
+
+	return imageDigests, nil
+}
+
+// getPlatformKey returns the key of the platform in the platform digests. The config of an
+// image often leaves out the variant that its image index sets (e.g. linux/arm64 and
+// linux/arm64/v8), so the default variant of the arm architectures is set when missing.
+func getPlatformKey(platform *v1.Platform) string {
+	if platform == nil {
+		return ""
+	}
+
+	normalized := *platform
+	if normalized.Variant == "" {
+		switch normalized.Architecture {
+		case "arm64":
+			normalized.Variant = "v8"
+		case "arm":
+			normalized.Variant = "v7"
+		}
+	}
+
+	return normalized.String()
+}
+
+// hasImageConfig returns true if the config of the manifest is a container image config.
+func hasImageConfig(rawManifest []byte) (bool, error) {
+	var imageManifest struct {
+		Config struct {
+			MediaType types.MediaType `json:"mediaType"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(rawManifest, &imageManifest); err != nil {
+		return false, fmt.Errorf("unmarshal manifest: %w", err)
+	}
+
+	return imageManifest.Config.MediaType == types.DockerConfigJSON || imageManifest.Config.MediaType == types.OCIConfigJSON, nil
+}
@@ -4,27 +4,31 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/avast/retry-go"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
-	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/plexsystems/sinker/internal/metrics"
+	log "github.com/sirupsen/logrus"
 )
 
 // Client manages the communication with the Docker client.
 type Client struct {
-	docker  *client.Client
-	logInfo func(format string, args ...interface{})
+	docker    *client.Client
+	transport http.RoundTripper
+	logger    log.FieldLogger
 }
 
-// New returns a Docker client configured with the given information logger.
-func New(logInfo func(format string, args ...interface{})) (Client, error) {
+// New returns a Docker client configured with the given logger.
+func New(logger log.FieldLogger) (Client, error) {
 	retry.DefaultDelay = 5 * time.Second
 	retry.DefaultAttempts = 2
 
@@ -34,13 +38,34 @@ func New(logInfo func(format string, args ...interface{})) (Client, error) {
 	}
 
 	client := Client{
-		docker:  dockerClient,
-		logInfo: logInfo,
+		docker:    dockerClient,
+		transport: metrics.NewTransport(remote.DefaultTransport),
+		logger:    logger,
 	}
 
 	return client, nil
 }
 
+// WithRequestTimeout returns a copy of the client that fails the requests to registries that
+// have not received a response within the timeout. The timeout does not limit how long the
+// body of a response, such as a layer, takes to read. A timeout of zero disables the timeout.
+func (c Client) WithRequestTimeout(timeout time.Duration) (Client, error) {
+	if timeout <= 0 {
+		return c, nil
+	}
+
+	base, ok := remote.DefaultTransport.(*http.Transport)
+	if !ok {
+		return Client{}, fmt.Errorf("unable to set a timeout on the transport %T", remote.DefaultTransport)
+	}
+
+	transport := base.Clone()
+	transport.ResponseHeaderTimeout = timeout
+	c.transport = metrics.NewTransport(transport)
+
+	return c, nil
+}
+
 // PushAndWait pushes an image and waits for it to finish pushing.
 // If an error occurs when pushing an image, the push will be attempted again before failing.
 func (c Client) PushAndWait(ctx context.Context, image string, auth string) error {
@@ -53,7 +78,7 @@ func (c Client) PushAndWait(ctx context.Context, image string, auth string) erro
 	}
 
 	retryFunc := func(attempts uint, err error) {
-		c.logInfo("Unable to push %v (Retrying #%v)", image, attempts+1)
+		c.logger.WithFields(log.Fields{"image": image, "phase": "push", "attempt": attempts + 1}).WithError(err).Info("Unable to push image. Retrying ...")
 	}
 
 	if err := retry.Do(push, retry.OnRetry(retryFunc)); err != nil {
@@ -75,7 +100,7 @@ func (c Client) PullAndWait(ctx context.Context, image string, auth string) erro
 	}
 
 	retryFunc := func(attempts uint, err error) {
-		c.logInfo("Unable to pull %v (Retrying #%v)", image, attempts+1)
+		c.logger.WithFields(log.Fields{"image": image, "phase": "pull", "attempt": attempts + 1}).WithError(err).Info("Unable to pull image. Retrying ...")
 	}
 
 	if err := retry.Do(pull, retry.OnRetry(retryFunc)); err != nil {
@@ -151,7 +176,7 @@ func (c Client) GetTagsForRepository(ctx context.Context, host string, repositor
 		return nil, fmt.Errorf("new repo: %w", err)
 	}
 
-	tags, err := remote.ListWithContext(ctx, repo, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	tags, err := remote.List(repo, c.remoteOptions(ctx)...)
 	if err != nil {
 		return nil, fmt.Errorf("list: %w", err)
 	}
@@ -159,6 +184,36 @@ func (c Client) GetTagsForRepository(ctx context.Context, host string, repositor
 	return tags, nil
 }
 
+// GetDigest returns the digest of the image at the remote registry.
+func (c Client) GetDigest(ctx context.Context, image string) (string, error) {
+	reference, err := name.ParseReference(image, name.WeakValidation)
+	if err != nil {
+		return "", fmt.Errorf("parse ref: %w", err)
+	}
+
+	descriptor, err := remote.Head(reference, c.remoteOptions(ctx)...)
+	if err != nil {
+		return "", fmt.Errorf("head: %w", err)
+	}
+
+	return descriptor.Digest.String(), nil
+}
+
+// DeleteImage deletes the image from the remote registry. The image should be
+// referenced by digest, as not every registry supports deleting tags.
+func (c Client) DeleteImage(ctx context.Context, image string) error {
+	reference, err := name.ParseReference(image, name.WeakValidation)
+	if err != nil {
+		return fmt.Errorf("parse ref: %w", err)
+	}
+
+	if err := remote.Delete(reference, c.remoteOptions(ctx)...); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
 // Tag creates a new tag from the given target image that references the source image.
 func (c Client) Tag(ctx context.Context, sourceImage string, targetImage string) error {
 	if err := c.docker.ImageTag(ctx, sourceImage, targetImage); err != nil {
@@ -175,21 +230,9 @@ func (c Client) ImageExistsAtRemote(ctx context.Context, image string) (bool, er
 		return false, fmt.Errorf("parse ref: %w", err)
 	}
 
-	if _, err := remote.Get(reference, remote.WithAuthFromKeychain(authn.DefaultKeychain)); err != nil {
-
-		// If the error is a transport error, check that the error code is of type
-		// MANIFEST_UNKNOWN or NOT_FOUND. These errors are expected if an image does
-		// not exist in the registry.
-		if t, exists := err.(*transport.Error); exists {
-			for _, diagnostic := range t.Errors {
-				if strings.EqualFold("MANIFEST_UNKNOWN", string(diagnostic.Code)) {
-					return false, nil
-				}
-
-				if strings.EqualFold("NOT_FOUND", string(diagnostic.Code)) {
-					return false, nil
-				}
-			}
+	if _, err := remote.Get(reference, c.remoteOptions(ctx)...); err != nil {
+		if IsNotFoundError(err) {
+			return false, nil
 		}
 
 		return false, fmt.Errorf("get image: %w", err)
@@ -209,6 +252,62 @@ func (c Client) ImageExistsAtRemote(ctx context.Context, image string) (bool, er
 	return true, nil
 }
 
+// IsNotFoundError returns true if the error is a transport error with an error
+// code of MANIFEST_UNKNOWN, NAME_UNKNOWN or NOT_FOUND. These errors are expected
+// if an image, or its repository, does not exist in the registry. Responses to HEAD
+// requests have no body, so a not found status without error codes is also not found.
+func IsNotFoundError(err error) bool {
+	var transportError *transport.Error
+	if !errors.As(err, &transportError) {
+		return false
+	}
+
+	if transportError.StatusCode == http.StatusNotFound && len(transportError.Errors) == 0 {
+		return true
+	}
+
+	for _, diagnostic := range transportError.Errors {
+		if strings.EqualFold("MANIFEST_UNKNOWN", string(diagnostic.Code)) {
+			return true
+		}
+
+		if strings.EqualFold("NAME_UNKNOWN", string(diagnostic.Code)) {
+			return true
+		}
+
+		if strings.EqualFold("NOT_FOUND", string(diagnostic.Code)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsUnauthorizedError returns true if the error is a transport error caused by
+// the client not being authorized to access the image.
+func IsUnauthorizedError(err error) bool {
+	var transportError *transport.Error
+	if !errors.As(err, &transportError) {
+		return false
+	}
+
+	if transportError.StatusCode == http.StatusUnauthorized || transportError.StatusCode == http.StatusForbidden {
+		return true
+	}
+
+	for _, diagnostic := range transportError.Errors {
+		if strings.EqualFold("UNAUTHORIZED", string(diagnostic.Code)) {
+			return true
+		}
+
+		if strings.EqualFold("DENIED", string(diagnostic.Code)) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type progressDetail struct {
 	Current int `json:"current"`
 	Total   int `json:"total"`
@@ -238,8 +337,12 @@ func (c Client) waitForScannerComplete(clientScanner *bufio.Scanner, image strin
 
 		// Serves as makeshift polling to occasionally print the status of the Docker command.
 		if scans%25 == 0 && status.ProgressDetail.Total > 0 {
-			progress := fmt.Sprintf("Processing %vB of %vB", status.ProgressDetail.Current, status.ProgressDetail.Total)
-			c.logInfo("%sing %s (%s)", command, image, progress)
+			c.logger.WithFields(log.Fields{
+				"image":   image,
+				"phase":   strings.ToLower(command),
+				"current": status.ProgressDetail.Current,
+				"total":   status.ProgressDetail.Total,
+			}).Infof("Processing %vB of %vB", status.ProgressDetail.Current, status.ProgressDetail.Total)
 		}
 
 		scans++
@@ -1,6 +1,13 @@
 package docker
 
-import "testing"
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
 
 func TestImageExists_DockerIO(t *testing.T) {
 	imagesOnHost := []string{"busybox:1.0.0", "plexsystems/busybox:1.0.0"}
@@ -23,3 +30,54 @@ func TestImageExists_DockerIO_WithLibrary(t *testing.T) {
 		t.Errorf("expected docker.io address to exist, but it did not.")
 	}
 }
+
+func TestIsNotFoundError(t *testing.T) {
+	codes := []transport.ErrorCode{transport.ManifestUnknownErrorCode, transport.NameUnknownErrorCode, "NOT_FOUND"}
+	for _, code := range codes {
+		err := fmt.Errorf("get image: %w", &transport.Error{Errors: []transport.Diagnostic{{Code: code}}})
+		if !IsNotFoundError(err) {
+			t.Errorf("expected %s to be a not found error", code)
+		}
+	}
+
+	err := &transport.Error{Errors: []transport.Diagnostic{{Code: transport.UnauthorizedErrorCode}}}
+	if IsNotFoundError(err) {
+		t.Errorf("expected %s to not be a not found error", transport.UnauthorizedErrorCode)
+	}
+}
+
+func TestIsUnauthorizedError(t *testing.T) {
+	statusCodes := []int{http.StatusUnauthorized, http.StatusForbidden}
+	for _, statusCode := range statusCodes {
+		err := fmt.Errorf("get image: %w", &transport.Error{StatusCode: statusCode})
+		if !IsUnauthorizedError(err) {
+			t.Errorf("expected status code %d to be an unauthorized error", statusCode)
+		}
+	}
+
+	err := &transport.Error{StatusCode: http.StatusNotFound, Errors: []transport.Diagnostic{{Code: transport.ManifestUnknownErrorCode}}}
+	if IsUnauthorizedError(err) {
+		t.Errorf("expected status code %d to not be an unauthorized error", http.StatusNotFound)
+	}
+}
+
+func TestGetPlatformKey(t *testing.T) {
+	testCases := []struct {
+		platform *v1.Platform
+		expected string
+	}{
+		{nil, ""},
+		{&v1.Platform{OS: "linux", Architecture: "amd64"}, "linux/amd64"},
+		{&v1.Platform{OS: "linux", Architecture: "arm64"}, "linux/arm64/v8"},
+		{&v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, "linux/arm64/v8"},
+		{&v1.Platform{OS: "linux", Architecture: "arm"}, "linux/arm/v7"},
+		{&v1.Platform{OS: "linux", Architecture: "arm", Variant: "v6"}, "linux/arm/v6"},
+	}
+
+	for _, testCase := range testCases {
+		actual := getPlatformKey(testCase.platform)
+		if actual != testCase.expected {
+			t.Errorf("expected platform key %s, actual %s", testCase.expected, actual)
+		}
+	}
+}
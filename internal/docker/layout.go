@@ -0,0 +1,36 @@
+package docker
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+)
+
+// refNameAnnotation is the annotation used to name the images in an OCI layout.
+const refNameAnnotation = "org.opencontainers.image.ref.name"
+
+// GetImagesInLayout returns the names of all of the images in the OCI layout
+// located at the specified path.
+func GetImagesInLayout(path string) ([]string, error) {
+	index, err := layout.ImageIndexFromPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("get index manifest: %w", err)
+	}
+
+	var images []string
+	for _, descriptor := range indexManifest.Manifests {
+		image, exists := descriptor.Annotations[refNameAnnotation]
+		if !exists {
+			continue
+		}
+
+		images = append(images, image)
+	}
+
+	return images, nil
+}
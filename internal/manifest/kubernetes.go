@@ -64,6 +64,27 @@ func GetImagesFromKubernetesResources(resources []string) ([]string, error) {
 }
 
 func getResourceContentsFromYamlFiles(path string) ([]string, error) {
+	filePaths, err := GetKubernetesManifestPaths(path)
+	if err != nil {
+		return nil, fmt.Errorf("get manifest paths: %w", err)
+	}
+
+	var fileContents []string
+	for _, filePath := range filePaths {
+		contents, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("read file: %w", err)
+		}
+
+		fileContents = append(fileContents, string(contents))
+	}
+
+	return fileContents, nil
+}
+
+// GetKubernetesManifestPaths returns the paths of all yaml files that are located
+// at the specified path, which can either be a directory or a single file.
+func GetKubernetesManifestPaths(path string) ([]string, error) {
 	var filePaths []string
 	err := filepath.Walk(path, func(currentFilePath string, fileInfo os.FileInfo, err error) error {
 		if err != nil {
@@ -90,17 +111,7 @@ func getResourceContentsFromYamlFiles(path string) ([]string, error) {
 		return nil, err
 	}
 
-	var fileContents []string
-	for _, filePath := range filePaths {
-		contents, err := os.ReadFile(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("read file: %w", err)
-		}
-
-		fileContents = append(fileContents, string(contents))
-	}
-
-	return fileContents, nil
+	return filePaths, nil
 }
 
 func getImagesFromResource(resource string) ([]string, error) {
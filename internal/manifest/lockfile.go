@@ -0,0 +1,76 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Lockfile records the digests of the source images at the time they were copied.
+type Lockfile struct {
+	Images []LockedImage `yaml:"images"`
+}
+
+// LockedImage is a source image and the digest it had when it was copied.
+type LockedImage struct {
+	Image  string `yaml:"image"`
+	Digest string `yaml:"digest"`
+}
+
+// GetLockfile returns the lockfile found at the specified path.
+func GetLockfile(path string) (Lockfile, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return Lockfile{}, fmt.Errorf("reading lockfile: %w", err)
+	}
+
+	var lockfile Lockfile
+	if err := yaml.Unmarshal(contents, &lockfile); err != nil {
+		return Lockfile{}, fmt.Errorf("unmarshal lockfile: %w", err)
+	}
+
+	return lockfile, nil
+}
+
+// Write writes the contents of the lockfile to disk at the specified path.
+func (l Lockfile) Write(path string) error {
+	sort.Slice(l.Images, func(i, j int) bool {
+		return l.Images[i].Image < l.Images[j].Image
+	})
+
+	contents, err := yaml.Marshal(&l)
+	if err != nil {
+		return fmt.Errorf("marshal lockfile: %w", err)
+	}
+
+	if err := os.WriteFile(path, contents, os.ModePerm); err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+
+	return nil
+}
+
+// Digest returns the digest recorded for the image, if any.
+func (l Lockfile) Digest(image string) (string, bool) {
+	for _, lockedImage := range l.Images {
+		if lockedImage.Image == image {
+			return lockedImage.Digest, true
+		}
+	}
+
+	return "", false
+}
+
+// Set records the digest of the image, replacing any digest previously recorded.
+func (l *Lockfile) Set(image string, digest string) {
+	for i := range l.Images {
+		if l.Images[i].Image == image {
+			l.Images[i].Digest = digest
+			return
+		}
+	}
+
+	l.Images = append(l.Images, LockedImage{Image: image, Digest: digest})
+}
@@ -0,0 +1,38 @@
+package manifest
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLockfile(t *testing.T) {
+	var lockfile Lockfile
+	lockfile.Set("nginx:1.19.0", "sha256:old")
+	lockfile.Set("busybox:1.32.0", "sha256:busybox")
+	lockfile.Set("nginx:1.19.0", "sha256:new")
+
+	path := filepath.Join(t.TempDir(), "images.lock")
+	if err := lockfile.Write(path); err != nil {
+		t.Fatal("write lockfile:", err)
+	}
+
+	actual, err := GetLockfile(path)
+	if err != nil {
+		t.Fatal("get lockfile:", err)
+	}
+
+	expected := Lockfile{
+		Images: []LockedImage{
+			{Image: "busybox:1.32.0", Digest: "sha256:busybox"},
+			{Image: "nginx:1.19.0", Digest: "sha256:new"},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected lockfile %v, actual %v", expected, actual)
+	}
+
+	if _, exists := actual.Digest("alpine:3.12"); exists {
+		t.Errorf("expected alpine:3.12 to not be in the lockfile")
+	}
+}
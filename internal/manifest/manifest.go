@@ -9,9 +9,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/plexsystems/sinker/internal/docker"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	"gopkg.in/yaml.v2"
 )
 
@@ -23,7 +25,7 @@ type Manifest struct {
 
 // Get returns the manifest found at the specified path.
 func Get(path string) (Manifest, error) {
-	manifestLocation := getManifestLocation(path)
+	manifestLocation := Location(path)
 	manifestContents, err := os.ReadFile(manifestLocation)
 	if err != nil {
 		return Manifest{}, fmt.Errorf("reading manifest: %w", err)
@@ -46,13 +48,26 @@ func Get(path string) (Manifest, error) {
 }
 
 // Write writes the contents of the manifest to disk at the specified path.
+//
+// Sources whose target is the same as the target of the manifest are written
+// without a target, as the target of the manifest is used when it is read.
 func (m Manifest) Write(path string) error {
+	var sources []Source
+	for _, source := range m.Sources {
+		if source.Target == m.Target {
+			source.Target = Target{}
+		}
+
+		sources = append(sources, source)
+	}
+	m.Sources = sources
+
 	imageManifestContents, err := yaml.Marshal(&m)
 	if err != nil {
 		return fmt.Errorf("marshal image manifest: %w", err)
 	}
 
-	manifestLocation := getManifestLocation(path)
+	manifestLocation := Location(path)
 	if err := os.WriteFile(manifestLocation, imageManifestContents, os.ModePerm); err != nil {
 		return fmt.Errorf("creating file: %w", err)
 	}
@@ -96,6 +111,11 @@ func (m Manifest) Update(images []string) Manifest {
 		updatedSource.Repository = foundSource.Repository
 		updatedSource.Host = foundSource.Host
 		updatedSource.Auth = foundSource.Auth
+		updatedSource.Verify = foundSource.Verify
+		updatedSource.Compression = foundSource.Compression
+		updatedSource.ArtifactType = foundSource.ArtifactType
+		updatedSource.Versioning = foundSource.Versioning
+		updatedSource.Timeout = foundSource.Timeout
 
 		// If the target host (or repository) of the source does not match the manifest
 		// target host (or repository), it has been modified by the user.
@@ -130,6 +150,10 @@ type Target struct {
 	Host       string `yaml:"host,omitempty"`
 	Repository string `yaml:"repository,omitempty"`
 	Auth       Auth   `yaml:"auth,omitempty"`
+
+	// Compression is the compression format (e.g. gzip, zstd or zstd:chunked)
+	// of the layers that are written to the target.
+	Compression string `yaml:"compression,omitempty"`
 }
 
 // EncodedAuth returns the Base64 encoded auth for the target registry.
@@ -151,6 +175,12 @@ func (t Target) EncodedAuth() (string, error) {
 	return auth, nil
 }
 
+// SupportsNestedRepositories returns true when the target registry supports nested
+// repositories, in which case the full repository of each source is kept at the target.
+func (t Target) SupportsNestedRepositories() bool {
+	return hostSupportsNestedRepositories(t.Host)
+}
+
 // Source is a container image in the manifest.
 type Source struct {
 	Repository string `yaml:"repository"`
@@ -159,6 +189,58 @@ type Source struct {
 	Tag        string `yaml:"tag,omitempty"`
 	Digest     string `yaml:"digest,omitempty"`
 	Auth       Auth   `yaml:"auth,omitempty"`
+
+	// Verify contains the keys the source image must be signed with
+	// in order to be copied.
+	Verify Verification `yaml:"verify,omitempty"`
+
+	// Compression is the compression format of the layers that are written to
+	// the target. When set, it takes precedence over the compression of the target.
+	Compression string `yaml:"compression,omitempty"`
+
+	// ArtifactType is the type of a non-image OCI artifact, such as a Helm chart
+	// (application/vnd.cncf.helm.config.v1+json). Artifacts are copied as is.
+	ArtifactType string `yaml:"artifactType,omitempty"`
+
+	// Versioning configures how the tags of the source are compared
+	// when checking for newer versions.
+	Versioning Versioning `yaml:"versioning,omitempty"`
+
+	// Timeout is how long the image of the source can take to be copied, pushed,
+	// pulled or checked. When set, it takes precedence over the image timeout flag.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Verification contains the keys used to verify the signatures of a source image.
+type Verification struct {
+	SigstorePublicKey string `yaml:"sigstorePublicKey,omitempty"`
+	GPGKey            string `yaml:"gpgKey,omitempty"`
+}
+
+// Versioning is the versioning scheme of the tags of a source.
+type Versioning struct {
+	// Scheme is the versioning scheme of the tags: semver (default), calver or regex.
+	Scheme string `yaml:"scheme,omitempty"`
+
+	// Pattern is the regular expression a tag must match when using the regex scheme.
+	// The capture groups of the pattern are the segments of the version.
+	Pattern string `yaml:"pattern,omitempty"`
+
+	// Order is the order, starting at 1, in which the capture groups of the pattern are
+	// compared. When not set, the capture groups are compared in the order they appear.
+	Order []int `yaml:"order,omitempty"`
+
+	// Variant is a suffix of the tags (e.g. -alpine) that newer versions must also have.
+	Variant string `yaml:"variant,omitempty"`
+
+	// PreRelease includes, or excludes, pre-release versions. When not set, only alpha,
+	// beta and rc pre-releases are included.
+	PreRelease *bool `yaml:"preRelease,omitempty"`
+}
+
+// IsArtifact returns true if the source is a non-image OCI artifact.
+func (s Source) IsArtifact() bool {
+	return s.ArtifactType != ""
 }
 
 // Image returns the source image including its tag or digest.
@@ -316,6 +398,43 @@ func getSourceHostFromRepository(repository string) string {
 	return ""
 }
 
+// FindSourceForImage returns the source in the manifest whose image is the given
+// image, including its tag or digest.
+func (m Manifest) FindSourceForImage(image string) (Source, bool) {
+	imageName := normalizeImage(image)
+	for _, source := range m.Sources {
+		if normalizeImage(source.Image()) == imageName {
+			return source, true
+		}
+	}
+
+	return Source{}, false
+}
+
+// FindSourceForTargetImage returns the source in the manifest whose target image
+// is the given image, including its tag or digest.
+func (m Manifest) FindSourceForTargetImage(image string) (Source, bool) {
+	imageName := normalizeImage(image)
+	for _, source := range m.Sources {
+		if normalizeImage(source.TargetImage()) == imageName {
+			return source, true
+		}
+	}
+
+	return Source{}, false
+}
+
+// normalizeImage returns the fully qualified name of the image so that
+// images such as busybox:1.0.0 and docker.io/library/busybox:1.0.0 are equal.
+func normalizeImage(image string) string {
+	reference, err := name.ParseReference(image, name.WeakValidation)
+	if err != nil {
+		return image
+	}
+
+	return reference.Name()
+}
+
 func (m Manifest) findSourceInManifest(image string) (Source, bool) {
 	for _, currentSource := range m.Sources {
 		imagePath := docker.RegistryPath(image)
@@ -334,7 +453,10 @@ func (m Manifest) findSourceInManifest(image string) (Source, bool) {
 	return Source{}, false
 }
 
-func getManifestLocation(path string) string {
+// Location returns the location of the manifest file for the specified path.
+// When the path does not refer to a yaml file, the default manifest file name
+// is used within the path.
+func Location(path string) string {
 	const defaultManifestFileName = ".images.yaml"
 
 	location := path
@@ -5,6 +5,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestSource_WithoutRepository(t *testing.T) {
@@ -429,6 +430,31 @@ func TestManifest_Update(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:  "preserves source timeout from manifest",
+			input: []string{"mycr.com/foo/bar:1.2.3"},
+			existingManifest: Manifest{
+				Target: base.Target,
+				Sources: []Source{
+					{
+						Repository: "foo/bar",
+						Tag:        "1.0.0",
+						Target:     base.Target,
+						Timeout:    time.Hour,
+					},
+				},
+			},
+			expected: Manifest{
+				Target: base.Target,
+				Sources: []Source{
+					{
+						Repository: "foo/bar",
+						Tag:        "1.2.3",
+						Timeout:    time.Hour,
+					},
+				},
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -440,3 +466,68 @@ func TestManifest_Update(t *testing.T) {
 		})
 	}
 }
+
+func TestManifest_Write_OmitsManifestTarget(t *testing.T) {
+	target := Target{Host: "mycr.com", Repository: "foo"}
+	otherTarget := Target{Host: "myothercr.com"}
+
+	imageManifest := Manifest{
+		Target: target,
+		Sources: []Source{
+			{Repository: "bar", Tag: "1.0.0", Target: target},
+			{Repository: "baz", Tag: "1.0.0", Target: otherTarget},
+		},
+	}
+
+	path := t.TempDir()
+	if err := imageManifest.Write(path); err != nil {
+		t.Fatal("write manifest:", err)
+	}
+
+	contents, err := os.ReadFile(Location(path))
+	if err != nil {
+		t.Fatal("read manifest:", err)
+	}
+
+	expected := `target:
+  host: mycr.com
+  repository: foo
+sources:
+- repository: bar
+  tag: 1.0.0
+- repository: baz
+  target:
+    host: myothercr.com
+  tag: 1.0.0
+`
+	if string(contents) != expected {
+		t.Errorf("expected manifest %s, actual %s", expected, contents)
+	}
+
+	if imageManifest.Sources[0].Target != target {
+		t.Errorf("expected the manifest being written to not be modified")
+	}
+}
+
+func TestManifest_Timeout(t *testing.T) {
+	imageManifest := Manifest{
+		Target: Target{Host: "mycr.com"},
+		Sources: []Source{
+			{Repository: "nvidia/cuda", Tag: "12.0.0", Timeout: 90 * time.Minute},
+		},
+	}
+
+	path := t.TempDir()
+	if err := imageManifest.Write(path); err != nil {
+		t.Fatal("write manifest:", err)
+	}
+
+	actual, err := Get(path)
+	if err != nil {
+		t.Fatal("get manifest:", err)
+	}
+
+	if actual.Sources[0].Timeout != 90*time.Minute {
+		t.Errorf("expected timeout %s, actual %s", 90*time.Minute, actual.Sources[0].Timeout)
+	}
+}
@@ -0,0 +1,142 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// replacement is an image found at a line and column of a yaml file
+// that should be replaced by another image.
+type replacement struct {
+	line     int
+	column   int
+	image    string
+	newImage string
+}
+
+// RewriteImages replaces the images in the yaml contents with the image they map to.
+// Like the images found in Kubernetes manifests, an image is only replaced when it is the
+// image of a container or init container (e.g. image: busybox:1.0.0), or an argument of
+// the container that sets it (e.g. --image=busybox:1.0.0).
+//
+// Only the images themselves are replaced, so the formatting and comments of the
+// contents are preserved.
+func RewriteImages(contents []byte, images map[string]string) ([]byte, error) {
+	var replacements []replacement
+
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("decode yaml: %w", err)
+		}
+
+		replacements = append(replacements, findReplacements(&document, images)...)
+	}
+
+	// The replacements are made from the end of the contents to the start so that the
+	// lines and columns of the remaining replacements are not moved by a replacement.
+	sort.Slice(replacements, func(i, j int) bool {
+		if replacements[i].line != replacements[j].line {
+			return replacements[i].line > replacements[j].line
+		}
+
+		return replacements[i].column > replacements[j].column
+	})
+
+	lines := strings.Split(string(contents), "\n")
+	for _, replacement := range replacements {
+		line := lines[replacement.line-1]
+
+		offset := getByteOffset(line, replacement.column-1)
+		index := strings.Index(line[offset:], replacement.image)
+		if index == -1 {
+			return nil, fmt.Errorf("image %s not found on line %d", replacement.image, replacement.line)
+		}
+
+		start := offset + index
+		lines[replacement.line-1] = line[:start] + replacement.newImage + line[start+len(replacement.image):]
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// findReplacements returns the replacements of the images of the containers
+// and init containers found anywhere in the node.
+func findReplacements(node *yaml.Node, images map[string]string) []replacement {
+	var replacements []replacement
+	if node.Kind != yaml.MappingNode {
+		for _, child := range node.Content {
+			replacements = append(replacements, findReplacements(child, images)...)
+		}
+
+		return replacements
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if (key.Value == "containers" || key.Value == "initContainers") && value.Kind == yaml.SequenceNode {
+			for _, container := range value.Content {
+				replacements = append(replacements, findContainerReplacements(container, images)...)
+			}
+
+			continue
+		}
+
+		replacements = append(replacements, findReplacements(value, images)...)
+	}
+
+	return replacements
+}
+
+// findContainerReplacements returns the replacements of the image of
+// the container, and of the images set by the arguments of the container.
+func findContainerReplacements(container *yaml.Node, images map[string]string) []replacement {
+	if container.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var replacements []replacement
+	for i := 0; i+1 < len(container.Content); i += 2 {
+		key, value := container.Content[i], container.Content[i+1]
+		switch {
+		case key.Value == "image" && value.Kind == yaml.ScalarNode:
+			for image, newImage := range images {
+				if value.Value == image {
+					replacements = append(replacements, replacement{line: value.Line, column: value.Column, image: image, newImage: newImage})
+				}
+			}
+		case key.Value == "args" && value.Kind == yaml.SequenceNode:
+			for _, arg := range value.Content {
+				for image, newImage := range images {
+					if arg.Value == image || strings.HasSuffix(arg.Value, "="+image) {
+						replacements = append(replacements, replacement{line: arg.Line, column: arg.Column, image: image, newImage: newImage})
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return replacements
+}
+
+// getByteOffset returns the byte offset of the character at the given column.
+func getByteOffset(line string, column int) int {
+	offset := 0
+	for i := 0; i < column && offset < len(line); i++ {
+		_, size := utf8.DecodeRuneInString(line[offset:])
+		offset += size
+	}
+
+	return offset
+}
@@ -0,0 +1,136 @@
+package manifest
+
+import (
+	"testing"
+)
+
+func TestRewriteImages(t *testing.T) {
+	contents := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app # the app
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: "busybox:1.0.0"
+      containers:
+      - name: app
+        image: quay.io/app/app:v1.0.0
+        args: ["--sidecar=busybox:1.0.0", "--port=8080"]
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+spec:
+  containers:
+  - name: pod
+    image: 'busybox:1.0.0'
+`
+
+	images := map[string]string{
+		"busybox:1.0.0":          "mycr.com/busybox:1.0.0",
+		"quay.io/app/app:v1.0.0": "mycr.com/app/app:v1.0.0@sha256:abc",
+	}
+
+	actual, err := RewriteImages([]byte(contents), images)
+	if err != nil {
+		t.Fatal("rewrite images:", err)
+	}
+
+	expected := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app # the app
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: "mycr.com/busybox:1.0.0"
+      containers:
+      - name: app
+        image: mycr.com/app/app:v1.0.0@sha256:abc
+        args: ["--sidecar=mycr.com/busybox:1.0.0", "--port=8080"]
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+spec:
+  containers:
+  - name: pod
+    image: 'mycr.com/busybox:1.0.0'
+`
+
+	if string(actual) != expected {
+		t.Errorf("expected rewritten contents %s, actual %s", expected, actual)
+	}
+}
+
+func TestRewriteImagesOnlyContainers(t *testing.T) {
+	contents := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  annotations:
+    image: busybox:1.0.0
+data:
+  image: busybox:1.0.0
+  args: --image=busybox:1.0.0
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+spec:
+  containers:
+  - name: pod
+    image: quay.io/app/app:v1.0.0
+    env:
+    - name: IMAGE
+      value: busybox:1.0.0
+`
+
+	images := map[string]string{
+		"busybox:1.0.0": "mycr.com/busybox:1.0.0",
+	}
+
+	actual, err := RewriteImages([]byte(contents), images)
+	if err != nil {
+		t.Fatal("rewrite images:", err)
+	}
+
+	if string(actual) != contents {
+		t.Errorf("expected only container images to be rewritten, actual %s", actual)
+	}
+}
+
+func TestManifest_FindSourceForImage(t *testing.T) {
+	imageManifest := Manifest{
+		Sources: []Source{
+			{Repository: "busybox", Tag: "1.0.0"},
+			{Repository: "app/app", Host: "quay.io", Tag: "v1.0.0"},
+		},
+	}
+
+	testCases := []struct {
+		image    string
+		expected bool
+	}{
+		{"busybox:1.0.0", true},
+		{"docker.io/library/busybox:1.0.0", true},
+		{"busybox:1.1.0", false},
+		{"quay.io/app/app:v1.0.0", true},
+		{"app/app:v1.0.0", false},
+	}
+
+	for _, testCase := range testCases {
+		_, actual := imageManifest.FindSourceForImage(testCase.image)
+		if actual != testCase.expected {
+			t.Errorf("expected source for %s to be found to be %v, actual %v", testCase.image, testCase.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "sinker"
+
+var (
+	// ImagesChecked is the number of images checked for whether they exist at the target.
+	ImagesChecked = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "images_checked_total",
+		Help:      "Number of images checked for whether they exist at the target.",
+	}, []string{"source_host", "target"})
+
+	// ImagesCopied is the number of images copied to the target.
+	ImagesCopied = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "images_copied_total",
+		Help:      "Number of images copied to the target.",
+	}, []string{"source_host", "target"})
+
+	// ImagesSkipped is the number of images that were not copied as they already exist at the target.
+	ImagesSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "images_skipped_total",
+		Help:      "Number of images that were not copied as they already exist at the target.",
+	}, []string{"source_host", "target"})
+
+	// ImagesFailed is the number of images that could not be checked or copied.
+	ImagesFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "images_failed_total",
+		Help:      "Number of images that could not be checked or copied.",
+	}, []string{"source_host", "target"})
+
+	// BytesTransferred is the number of bytes of the layers copied to the target.
+	BytesTransferred = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bytes_transferred_total",
+		Help:      "Number of bytes of the layers copied to the target.",
+	}, []string{"source_host", "target"})
+
+	// CopyDuration is the duration of copying an image to the target.
+	CopyDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "copy_duration_seconds",
+		Help:      "Duration of copying an image to the target.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200},
+	}, []string{"source_host", "target"})
+
+	// LastSuccess is the time of the last sync that copied every image.
+	LastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Time of the last sync that copied every image.",
+	})
+
+	// APIRequestDuration is the duration of the registry API requests sinker makes itself.
+	// The requests made by containers/image to copy and sign images are not included.
+	APIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "registry_api_request_duration_seconds",
+		Help:      "Duration of the registry API requests made to check, list and resolve images and to copy artifacts, excluding the image copies.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"registry", "method", "code"})
+
+	// APIRequestErrors is the number of registry API requests sinker makes itself that failed or were rate limited.
+	APIRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registry_api_request_errors_total",
+		Help:      "Number of registry API requests made to check, list and resolve images and to copy artifacts that failed or were rate limited, excluding the image copies.",
+	}, []string{"registry", "method"})
+
+	// APIRateLimitRemaining is the number of requests remaining before a registry rate limits
+	// requests, as last reported in a response to the registry API requests sinker makes itself.
+	APIRateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "registry_api_rate_limit_remaining",
+		Help:      "Number of requests remaining before a registry rate limits requests, as last reported to the registry API requests made to check, list and resolve images and to copy artifacts.",
+	}, []string{"registry"})
+)
+
+// registry is the registry of the metrics of sinker. A registry of its own is used
+// so the metrics of the Go runtime are not written to the textfile of a single run.
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		ImagesChecked,
+		ImagesCopied,
+		ImagesSkipped,
+		ImagesFailed,
+		BytesTransferred,
+		CopyDuration,
+		LastSuccess,
+		APIRequestDuration,
+		APIRequestErrors,
+		APIRateLimitRemaining,
+	)
+}
+
+// Handler returns the handler that serves the metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// WriteTextfile writes the metrics to a file that can be read
+// by the textfile collector of the Prometheus node exporter.
+func WriteTextfile(path string) error {
+	if err := prometheus.WriteToTextfile(path, registry); err != nil {
+		return fmt.Errorf("write to textfile: %w", err)
+	}
+
+	return nil
+}
+
+// transport is a round tripper that records the metrics of the registry API requests sinker makes itself.
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport returns a round tripper that records the metrics of the
+// requests made to a registry, before passing them to the base round tripper.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	return transport{base: base}
+}
+
+// RoundTrip records the duration of the request, whether the request failed,
+// and the number of requests remaining before the registry rate limits requests.
+func (t transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	start := time.Now()
+	response, err := t.base.RoundTrip(request)
+	if err != nil {
+		APIRequestDuration.WithLabelValues(request.URL.Host, request.Method, "error").Observe(time.Since(start).Seconds())
+		APIRequestErrors.WithLabelValues(request.URL.Host, request.Method).Inc()
+		return nil, err
+	}
+
+	APIRequestDuration.WithLabelValues(request.URL.Host, request.Method, strconv.Itoa(response.StatusCode)).Observe(time.Since(start).Seconds())
+	if response.StatusCode >= http.StatusInternalServerError || response.StatusCode == http.StatusTooManyRequests {
+		APIRequestErrors.WithLabelValues(request.URL.Host, request.Method).Inc()
+	}
+
+	if remaining, err := getRateLimitRemaining(response.Header); err == nil {
+		APIRateLimitRemaining.WithLabelValues(request.URL.Host).Set(remaining)
+	}
+
+	return response, nil
+}
+
+// getRateLimitRemaining returns the number of requests remaining from the RateLimit-Remaining
+// header, which Docker Hub sets with the window of the rate limit (e.g. 76;w=21600).
+func getRateLimitRemaining(header http.Header) (float64, error) {
+	value := header.Get("RateLimit-Remaining")
+	if value == "" {
+		return 0, errors.New("rate limit remaining not set")
+	}
+
+	remaining, err := strconv.ParseFloat(strings.TrimSpace(strings.Split(value, ";")[0]), 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse rate limit remaining: %w", err)
+	}
+
+	return remaining, nil
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestGetRateLimitRemaining(t *testing.T) {
+	testCases := []struct {
+		value       string
+		expected    float64
+		expectedErr bool
+	}{
+		{"76;w=21600", 76, false},
+		{"100", 100, false},
+		{"", 0, true},
+		{"unknown", 0, true},
+	}
+
+	for _, testCase := range testCases {
+		header := http.Header{}
+		header.Set("RateLimit-Remaining", testCase.value)
+
+		actual, err := getRateLimitRemaining(header)
+		if (err != nil) != testCase.expectedErr {
+			t.Errorf("expected error for %s to be %v, actual %v", testCase.value, testCase.expectedErr, err)
+		}
+
+		if actual != testCase.expected {
+			t.Errorf("expected rate limit remaining of %s to be %v, actual %v", testCase.value, testCase.expected, actual)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("RateLimit-Remaining", "42;w=21600")
+		writer.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal("parse url:", err)
+	}
+
+	client := http.Client{Transport: NewTransport(http.DefaultTransport)}
+	response, err := client.Get(server.URL + "/v2/")
+	if err != nil {
+		t.Fatal("get:", err)
+	}
+	response.Body.Close()
+
+	actualErrors := testutil.ToFloat64(APIRequestErrors.WithLabelValues(serverURL.Host, http.MethodGet))
+	if actualErrors != 1 {
+		t.Errorf("expected 1 request error, actual %v", actualErrors)
+	}
+
+	actualRemaining := testutil.ToFloat64(APIRateLimitRemaining.WithLabelValues(serverURL.Host))
+	if actualRemaining != 42 {
+		t.Errorf("expected rate limit remaining of 42, actual %v", actualRemaining)
+	}
+}
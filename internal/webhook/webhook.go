@@ -0,0 +1,298 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	log "github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Webhook is a mutating admission webhook that replaces the images of
+// the resources being admitted with their target images.
+type Webhook struct {
+	manifest manifest.Manifest
+	deny     bool
+	logger   log.FieldLogger
+}
+
+// New returns a webhook that replaces the images of the sources in the manifest with their
+// target images. When deny is set, resources with images that are not mirrored are denied.
+func New(imageManifest manifest.Manifest, deny bool, logger log.FieldLogger) Webhook {
+	webhook := Webhook{
+		manifest: imageManifest,
+		deny:     deny,
+		logger:   logger,
+	}
+
+	return webhook
+}
+
+// patchOperation is a single operation of a JSON patch.
+type patchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+// podSpec contains the fields of a pod spec that have images.
+type podSpec struct {
+	InitContainers []corev1.Container `json:"initContainers"`
+	Containers     []corev1.Container `json:"containers"`
+
+	// Image is only found in the spec of Prometheus and Alertmanager resources, which can
+	// also set their image with a base image and the version used as its tag.
+	Image     string `json:"image"`
+	BaseImage string `json:"baseImage"`
+	Version   string `json:"version"`
+}
+
+// ServeHTTP responds to an AdmissionReview request with the review of the resource being admitted.
+func (w Webhook) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(request.Body).Decode(&review); err != nil {
+		http.Error(writer, fmt.Sprintf("decode admission review: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if review.Request == nil {
+		http.Error(writer, "admission review does not have a request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = w.Review(review.Request)
+	review.Request = nil
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(&review); err != nil {
+		http.Error(writer, fmt.Sprintf("encode admission review: %s", err), http.StatusInternalServerError)
+	}
+}
+
+// Review returns the response to the admission request. The response patches the images
+// of the resource to their target images, or denies the resource when the webhook denies
+// images that are not mirrored.
+func (w Webhook) Review(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := admissionv1.AdmissionResponse{
+		UID:     request.UID,
+		Allowed: true,
+	}
+
+	patch, unmirroredImages, err := w.getPatch(request.Kind.Kind, request.Object.Raw)
+	if err != nil {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("get patch: %s", err),
+		}
+
+		return &response
+	}
+
+	if w.deny && len(unmirroredImages) > 0 {
+		w.logger.WithFields(log.Fields{"resource": getResourceName(request), "images": unmirroredImages, "phase": "admit"}).Info("Denied resource with images that are not mirrored")
+
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Code:    http.StatusForbidden,
+			Message: fmt.Sprintf("images are not mirrored: %s", strings.Join(unmirroredImages, ", ")),
+		}
+
+		return &response
+	}
+
+	if len(patch) == 0 {
+		return &response
+	}
+
+	patchContents, err := json.Marshal(patch)
+	if err != nil {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Code:    http.StatusInternalServerError,
+			Message: fmt.Sprintf("marshal patch: %s", err),
+		}
+
+		return &response
+	}
+
+	for _, operation := range patch {
+		w.logger.WithFields(log.Fields{"resource": getResourceName(request), "path": operation.Path, "target": operation.Value, "phase": "mutate"}).Info("Replaced image")
+	}
+
+	patchType := admissionv1.PatchTypeJSONPatch
+	response.PatchType = &patchType
+	response.Patch = patchContents
+
+	return &response
+}
+
+// getPatch returns the JSON patch that replaces the images of the resource with their
+// target images, as well as the images of the resource that are not mirrored.
+func (w Webhook) getPatch(kind string, object []byte) ([]patchOperation, []string, error) {
+	if len(object) == 0 {
+		return nil, nil, nil
+	}
+
+	path := getPodSpecPath(kind)
+	spec, err := getPodSpec(object, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get pod spec: %w", err)
+	}
+
+	initContainersPatch, unmirroredInitImages := w.getContainersPatch(path+"/initContainers", spec.InitContainers)
+	containersPatch, unmirroredImages := w.getContainersPatch(path+"/containers", spec.Containers)
+
+	var patch []patchOperation
+	patch = append(patch, initContainersPatch...)
+	patch = append(patch, containersPatch...)
+	unmirroredImages = append(unmirroredInitImages, unmirroredImages...)
+
+	if spec.Image != "" {
+		targetImage, mirrored := w.getTargetImage(spec.Image)
+		if !mirrored {
+			unmirroredImages = append(unmirroredImages, spec.Image)
+		} else if targetImage != spec.Image {
+			patch = append(patch, patchOperation{Op: "replace", Path: path + "/image", Value: targetImage})
+		}
+	} else if spec.BaseImage != "" {
+		basePatch, unmirroredImage := w.getBaseImagePatch(path, spec.BaseImage, spec.Version)
+		patch = append(patch, basePatch...)
+		if unmirroredImage != "" {
+			unmirroredImages = append(unmirroredImages, unmirroredImage)
+		}
+	}
+
+	return patch, unmirroredImages, nil
+}
+
+// getBaseImagePatch returns the patch that replaces the base image of a Prometheus or
+// Alertmanager resource, whose image is the base image tagged with the version. The
+// version is the tag of the target image, so only the base image is replaced. When the
+// image is not mirrored, the image is returned.
+func (w Webhook) getBaseImagePatch(path string, baseImage string, version string) ([]patchOperation, string) {
+	image := baseImage
+	if version != "" {
+		image = baseImage + ":" + version
+	}
+
+	targetImage, mirrored := w.getTargetImage(image)
+	if !mirrored {
+		return nil, image
+	}
+
+	targetBaseImage := targetImage
+	if version != "" {
+		targetBaseImage = strings.TrimSuffix(targetImage, ":"+version)
+	}
+
+	if targetBaseImage == baseImage {
+		return nil, ""
+	}
+
+	return []patchOperation{{Op: "replace", Path: path + "/baseImage", Value: targetBaseImage}}, ""
+}
+
+func (w Webhook) getContainersPatch(path string, containers []corev1.Container) ([]patchOperation, []string) {
+	var patch []patchOperation
+	var unmirroredImages []string
+	for c, container := range containers {
+		containerPath := fmt.Sprintf("%s/%d", path, c)
+
+		targetImage, mirrored := w.getTargetImage(container.Image)
+		if !mirrored {
+			unmirroredImages = append(unmirroredImages, container.Image)
+		} else if targetImage != container.Image {
+			patch = append(patch, patchOperation{Op: "replace", Path: containerPath + "/image", Value: targetImage})
+		}
+
+		// Arguments can set an image (e.g. --config-reloader-image=busybox:1.0.0),
+		// in which case only the value of the argument is replaced.
+		for a, arg := range container.Args {
+			image := arg[strings.Index(arg, "=")+1:]
+
+			source, exists := w.manifest.FindSourceForImage(image)
+			if !exists {
+				continue
+			}
+
+			argPath := fmt.Sprintf("%s/args/%d", containerPath, a)
+			patch = append(patch, patchOperation{Op: "replace", Path: argPath, Value: strings.TrimSuffix(arg, image) + source.TargetImage()})
+		}
+	}
+
+	return patch, unmirroredImages
+}
+
+// getTargetImage returns the target image of the image. An image is mirrored when it
+// is a source in the manifest, or when it already is the target image of a source.
+func (w Webhook) getTargetImage(image string) (string, bool) {
+	if source, exists := w.manifest.FindSourceForImage(image); exists {
+		return source.TargetImage(), true
+	}
+
+	if _, exists := w.manifest.FindSourceForTargetImage(image); exists {
+		return image, true
+	}
+
+	return image, false
+}
+
+// getResourceName returns the kind and name of the resource being admitted. Pods created
+// by a controller do not have a name yet, in which case only the kind is returned.
+func getResourceName(request *admissionv1.AdmissionRequest) string {
+	name := request.Name
+	if name != "" && request.Namespace != "" {
+		name = request.Namespace + "/" + name
+	}
+
+	return strings.TrimSpace(request.Kind.Kind + " " + name)
+}
+
+// getPodSpecPath returns the path of the pod spec within a resource of the given kind.
+// Resources of any other kind are expected to have a pod template, which matches
+// how images are found in Kubernetes manifests.
+func getPodSpecPath(kind string) string {
+	switch kind {
+	case "Pod", "Prometheus", "Alertmanager":
+		return "/spec"
+	case "CronJob":
+		return "/spec/jobTemplate/spec/template/spec"
+	default:
+		return "/spec/template/spec"
+	}
+}
+
+func getPodSpec(object []byte, path string) (podSpec, error) {
+	contents := json.RawMessage(object)
+	for _, field := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(contents, &fields); err != nil {
+			return podSpec{}, fmt.Errorf("unmarshal %s: %w", field, err)
+		}
+
+		if _, exists := fields[field]; !exists {
+			return podSpec{}, nil
+		}
+
+		contents = fields[field]
+	}
+
+	var spec podSpec
+	if err := json.Unmarshal(contents, &spec); err != nil {
+		return podSpec{}, fmt.Errorf("unmarshal pod spec: %w", err)
+	}
+
+	return spec, nil
+}
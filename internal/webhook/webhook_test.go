@@ -0,0 +1,218 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexsystems/sinker/internal/manifest"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestServeHTTP(t *testing.T) {
+	imageManifest := manifest.Manifest{
+		Target: manifest.Target{Host: "mycr.com", Repository: "mirror"},
+		Sources: []manifest.Source{
+			{Repository: "busybox", Tag: "1.0.0", Target: manifest.Target{Host: "mycr.com", Repository: "mirror"}},
+			{Repository: "app/app", Host: "quay.io", Tag: "v1.0.0", Target: manifest.Target{Host: "mycr.com", Repository: "mirror"}},
+		},
+	}
+
+	deployment := `{
+		"apiVersion": "apps/v1",
+		"kind": "Deployment",
+		"spec": {
+			"template": {
+				"spec": {
+					"initContainers": [{"name": "init", "image": "busybox:1.0.0"}],
+					"containers": [
+						{"name": "app", "image": "quay.io/app/app:v1.0.0", "args": ["--sidecar=busybox:1.0.0", "--port=8080"]},
+						{"name": "mirrored", "image": "mycr.com/mirror/busybox:1.0.0"}
+					]
+				}
+			}
+		}
+	}`
+
+	review := getReview(t, New(imageManifest, false, newTestLogger()), "Deployment", deployment)
+	if !review.Response.Allowed {
+		t.Fatalf("expected deployment to be allowed, actual denied with %s", review.Response.Result.Message)
+	}
+
+	var actual []patchOperation
+	if err := json.Unmarshal(review.Response.Patch, &actual); err != nil {
+		t.Fatal("unmarshal patch:", err)
+	}
+
+	expected := []patchOperation{
+		{Op: "replace", Path: "/spec/template/spec/initContainers/0/image", Value: "mycr.com/mirror/busybox:1.0.0"},
+		{Op: "replace", Path: "/spec/template/spec/containers/0/image", Value: "mycr.com/mirror/app/app:v1.0.0"},
+		{Op: "replace", Path: "/spec/template/spec/containers/0/args/0", Value: "--sidecar=mycr.com/mirror/busybox:1.0.0"},
+	}
+
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d patch operations, actual %v", len(expected), actual)
+	}
+
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected patch operation %v, actual %v", expected[i], actual[i])
+		}
+	}
+
+	if review.Response.UID != "uid" {
+		t.Errorf("expected response uid %s, actual %s", "uid", review.Response.UID)
+	}
+}
+
+func TestServeHTTP_Deny(t *testing.T) {
+	imageManifest := manifest.Manifest{
+		Sources: []manifest.Source{
+			{Repository: "busybox", Tag: "1.0.0", Target: manifest.Target{Host: "mycr.com"}},
+		},
+	}
+
+	cronJob := `{
+		"apiVersion": "batch/v1",
+		"kind": "CronJob",
+		"spec": {
+			"jobTemplate": {
+				"spec": {
+					"template": {
+						"spec": {
+							"containers": [{"name": "job", "image": "busybox:1.1.0"}]
+						}
+					}
+				}
+			}
+		}
+	}`
+
+	review := getReview(t, New(imageManifest, true, newTestLogger()), "CronJob", cronJob)
+	if review.Response.Allowed {
+		t.Fatal("expected cronjob to be denied, actual allowed")
+	}
+
+	if review.Response.Result.Code != http.StatusForbidden {
+		t.Errorf("expected status code %d, actual %d", http.StatusForbidden, review.Response.Result.Code)
+	}
+
+	review = getReview(t, New(imageManifest, false, newTestLogger()), "CronJob", cronJob)
+	if !review.Response.Allowed {
+		t.Error("expected cronjob to be allowed when not denying, actual denied")
+	}
+
+	if len(review.Response.Patch) > 0 {
+		t.Errorf("expected no patch, actual %s", review.Response.Patch)
+	}
+}
+
+func TestServeHTTP_BaseImage(t *testing.T) {
+	imageManifest := manifest.Manifest{
+		Sources: []manifest.Source{
+			{Repository: "prometheus/prometheus", Host: "quay.io", Tag: "v2.22.0", Target: manifest.Target{Host: "mycr.com", Repository: "mirror"}},
+		},
+	}
+
+	prometheus := `{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind": "Prometheus",
+		"spec": {
+			"baseImage": "quay.io/prometheus/prometheus",
+			"version": "v2.22.0"
+		}
+	}`
+
+	review := getReview(t, New(imageManifest, true, newTestLogger()), "Prometheus", prometheus)
+	if !review.Response.Allowed {
+		t.Fatalf("expected prometheus to be allowed, actual denied with %s", review.Response.Result.Message)
+	}
+
+	var actual []patchOperation
+	if err := json.Unmarshal(review.Response.Patch, &actual); err != nil {
+		t.Fatal("unmarshal patch:", err)
+	}
+
+	expected := patchOperation{Op: "replace", Path: "/spec/baseImage", Value: "mycr.com/mirror/prometheus/prometheus"}
+	if len(actual) != 1 || actual[0] != expected {
+		t.Errorf("expected patch operation %v, actual %v", expected, actual)
+	}
+
+	unmirrored := `{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind": "Prometheus",
+		"spec": {
+			"baseImage": "quay.io/prometheus/prometheus",
+			"version": "v2.23.0"
+		}
+	}`
+
+	review = getReview(t, New(imageManifest, true, newTestLogger()), "Prometheus", unmirrored)
+	if review.Response.Allowed {
+		t.Error("expected prometheus with a version that is not mirrored to be denied, actual allowed")
+	}
+}
+
+func TestGetPodSpecPath(t *testing.T) {
+	testCases := []struct {
+		kind     string
+		expected string
+	}{
+		{"Pod", "/spec"},
+		{"Deployment", "/spec/template/spec"},
+		{"StatefulSet", "/spec/template/spec"},
+		{"DaemonSet", "/spec/template/spec"},
+		{"Job", "/spec/template/spec"},
+		{"CronJob", "/spec/jobTemplate/spec/template/spec"},
+	}
+
+	for _, testCase := range testCases {
+		actual := getPodSpecPath(testCase.kind)
+		if actual != testCase.expected {
+			t.Errorf("expected pod spec path of %s to be %s, actual %s", testCase.kind, testCase.expected, actual)
+		}
+	}
+}
+
+func getReview(t *testing.T, webhook Webhook, kind string, object string) admissionv1.AdmissionReview {
+	request := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "uid",
+			Kind:      metav1.GroupVersionKind{Kind: kind},
+			Name:      "test",
+			Namespace: "default",
+			Object:    runtime.RawExtension{Raw: []byte(object)},
+		},
+	}
+
+	body, err := json.Marshal(&request)
+	if err != nil {
+		t.Fatal("marshal review:", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	webhook.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, actual %d: %s", http.StatusOK, recorder.Code, recorder.Body)
+	}
+
+	var review admissionv1.AdmissionReview
+	if err := json.Unmarshal(recorder.Body.Bytes(), &review); err != nil {
+		t.Fatal("unmarshal review:", err)
+	}
+
+	return review
+}
+
+func newTestLogger() *logrus.Logger {
+	logger, _ := test.NewNullLogger()
+	return logger
+}
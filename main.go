@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/plexsystems/sinker/internal/commands"
@@ -8,6 +9,11 @@ import (
 
 func main() {
 	if err := commands.NewDefaultCommand().Execute(); err != nil {
+		var exitError *commands.ExitError
+		if errors.As(err, &exitError) {
+			os.Exit(exitError.Code)
+		}
+
 		os.Exit(1)
 	}
 }